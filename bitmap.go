@@ -1,5 +1,11 @@
 package evdev
 
+import (
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
 type bitmap struct {
 	bits []byte
 }
@@ -31,3 +37,27 @@ func newBitmap(bits []byte) *bitmap {
 		bits: bits,
 	}
 }
+
+// parseBitmask parses a bitmask as exported by the kernel in sysfs and uevents,
+// which is a space separated list of hexadecimal words of the size of a long,
+// most significant word first. The result is a little-endian byte slice
+// suitable for newBitmap.
+func parseBitmask(s string) ([]byte, error) {
+	words := strings.Fields(s)
+	wordBytes := bits.UintSize / 8
+	b := make([]byte, len(words)*wordBytes)
+
+	for i, word := range words {
+		v, err := strconv.ParseUint(word, 16, bits.UintSize)
+		if err != nil {
+			return nil, err
+		}
+
+		offset := (len(words) - 1 - i) * wordBytes
+		for j := 0; j < wordBytes; j++ {
+			b[offset+j] = byte(v >> (8 * j))
+		}
+	}
+
+	return b, nil
+}
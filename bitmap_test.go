@@ -1,6 +1,7 @@
 package evdev
 
 import (
+	"math/bits"
 	"reflect"
 	"testing"
 )
@@ -68,3 +69,47 @@ func Test_bitmap_bitIsSet(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseBitmask(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    []int
+		wantErr bool
+	}{
+		{
+			name: "single word",
+			s:    "120013",
+			want: []int{0, 1, 4, 17, 20},
+		},
+		{
+			name: "multiple words",
+			s:    "3 0 1",
+			want: []int{0, bits.UintSize * 2, bits.UintSize*2 + 1},
+		},
+		{
+			name: "zero",
+			s:    "0",
+			want: nil,
+		},
+		{
+			name:    "invalid",
+			s:       "xyz",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := parseBitmask(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseBitmask() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := newBitmap(b).setBits(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseBitmask() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
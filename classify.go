@@ -0,0 +1,307 @@
+package evdev
+
+import (
+	"strings"
+)
+
+// DeviceClass is a bitset describing what kind of device an input device is.
+// The classes and their semantics follow udev's input_id builtin, so a device
+// is classified the same way as in the rest of the desktop stack. A device
+// can belong to several classes.
+type DeviceClass uint32
+
+const (
+	ClassKey           DeviceClass = 1 << iota // ID_INPUT_KEY: has keys
+	ClassKeyboard                              // ID_INPUT_KEYBOARD: full keyboard
+	ClassMouse                                 // ID_INPUT_MOUSE
+	ClassTouchpad                              // ID_INPUT_TOUCHPAD
+	ClassTouchscreen                           // ID_INPUT_TOUCHSCREEN
+	ClassTablet                                // ID_INPUT_TABLET
+	ClassTabletPad                             // ID_INPUT_TABLET_PAD
+	ClassJoystick                              // ID_INPUT_JOYSTICK
+	ClassAccelerometer                         // ID_INPUT_ACCELEROMETER
+	ClassPointingStick                         // ID_INPUT_POINTINGSTICK
+	ClassSwitch                                // ID_INPUT_SWITCH
+)
+
+var deviceClassNames = []struct {
+	class DeviceClass
+	name  string
+}{
+	{ClassKey, "ID_INPUT_KEY"},
+	{ClassKeyboard, "ID_INPUT_KEYBOARD"},
+	{ClassMouse, "ID_INPUT_MOUSE"},
+	{ClassTouchpad, "ID_INPUT_TOUCHPAD"},
+	{ClassTouchscreen, "ID_INPUT_TOUCHSCREEN"},
+	{ClassTablet, "ID_INPUT_TABLET"},
+	{ClassTabletPad, "ID_INPUT_TABLET_PAD"},
+	{ClassJoystick, "ID_INPUT_JOYSTICK"},
+	{ClassAccelerometer, "ID_INPUT_ACCELEROMETER"},
+	{ClassPointingStick, "ID_INPUT_POINTINGSTICK"},
+	{ClassSwitch, "ID_INPUT_SWITCH"},
+}
+
+// Has returns true if all classes in other are set in c.
+func (c DeviceClass) Has(other DeviceClass) bool {
+	return c&other == other
+}
+
+// String returns the names of the udev properties corresponding to the classes
+// in c, separated by "|".
+func (c DeviceClass) String() string {
+	var names []string
+
+	for _, n := range deviceClassNames {
+		if c.Has(n.class) {
+			names = append(names, n.name)
+		}
+	}
+
+	return strings.Join(names, "|")
+}
+
+// Classify returns the classes of the device, as udev would assign them.
+func (d *InputDevice) Classify() (DeviceClass, error) {
+	id, err := d.InputID()
+	if err != nil {
+		return 0, err
+	}
+
+	return classify(newCapabilitySet(deviceCapabilities(d), d.Properties()), id.BusType), nil
+}
+
+// Classify returns the classes of the device, as udev would assign them.
+func (info *DeviceInfo) Classify() DeviceClass {
+	return classify(newCapabilitySet(info.Capabilities, info.Properties), info.InputID.BusType)
+}
+
+type capabilitySet struct {
+	types map[EvType]bool
+	codes map[EvType]map[EvCode]bool
+	props map[EvProp]bool
+}
+
+func newCapabilitySet(capabilities map[EvType][]EvCode, props []EvProp) *capabilitySet {
+	s := &capabilitySet{
+		types: make(map[EvType]bool),
+		codes: make(map[EvType]map[EvCode]bool),
+		props: make(map[EvProp]bool),
+	}
+
+	for t, codes := range capabilities {
+		s.types[t] = true
+		s.codes[t] = make(map[EvCode]bool)
+
+		for _, code := range codes {
+			s.codes[t][code] = true
+		}
+	}
+
+	for _, p := range props {
+		s.props[p] = true
+	}
+
+	return s
+}
+
+func (s *capabilitySet) has(t EvType, code EvCode) bool {
+	return s.codes[t][code]
+}
+
+// count returns the number of codes of the given type in the range [from, to)
+func (s *capabilitySet) count(t EvType, from, to EvCode) int {
+	n := 0
+
+	for code := from; code < to; code++ {
+		if s.has(t, code) {
+			n++
+		}
+	}
+
+	return n
+}
+
+// classifyPointer follows test_pointers() in udev's input_id builtin
+func classifyPointer(s *capabilitySet, bus uint16) DeviceClass {
+	var class DeviceClass
+
+	hasKeys := s.types[EV_KEY]
+	hasAbsCoordinates := s.has(EV_ABS, ABS_X) && s.has(EV_ABS, ABS_Y)
+	has3DCoordinates := hasAbsCoordinates && s.has(EV_ABS, ABS_Z)
+
+	if s.props[INPUT_PROP_ACCELEROMETER] || (!hasKeys && has3DCoordinates) {
+		return ClassAccelerometer
+	}
+
+	isPointingStick := s.props[INPUT_PROP_POINTING_STICK]
+	hasStylus := s.has(EV_KEY, BTN_STYLUS)
+	hasPen := s.has(EV_KEY, BTN_TOOL_PEN)
+	fingerButNoPen := s.has(EV_KEY, BTN_TOOL_FINGER) && !hasPen
+	hasMouseButton := s.count(EV_KEY, BTN_MOUSE, BTN_JOYSTICK) > 0
+	hasRelCoordinates := s.types[EV_REL] && s.has(EV_REL, REL_X) && s.has(EV_REL, REL_Y)
+	hasMTCoordinates := s.has(EV_ABS, ABS_MT_POSITION_X) && s.has(EV_ABS, ABS_MT_POSITION_Y)
+
+	// unset hasMTCoordinates if the device claims to have all abs axes
+	if hasMTCoordinates && s.has(EV_ABS, ABS_MT_SLOT) && s.has(EV_ABS, ABS_MT_SLOT-1) {
+		hasMTCoordinates = false
+	}
+
+	isDirect := s.props[INPUT_PROP_DIRECT]
+	hasTouch := s.has(EV_KEY, BTN_TOUCH)
+	hasPadButtons := s.has(EV_KEY, BTN_0) && hasStylus && !hasPen
+	hasWheel := s.types[EV_REL] && (s.has(EV_REL, REL_WHEEL) || s.has(EV_REL, REL_HWHEEL))
+
+	// The BTN_JOYSTICK range starts after the mouse range, so a mouse with
+	// more than 16 buttons runs into the joystick range. Skip those.
+	numJoystickButtons := 0
+	if !s.has(EV_KEY, BTN_JOYSTICK-1) {
+		numJoystickButtons += s.count(EV_KEY, BTN_JOYSTICK, BTN_DIGI)
+		numJoystickButtons += s.count(EV_KEY, BTN_TRIGGER_HAPPY1, BTN_TRIGGER_HAPPY40+1)
+		numJoystickButtons += s.count(EV_KEY, BTN_DPAD_UP, BTN_DPAD_RIGHT+1)
+	}
+	numJoystickAxes := s.count(EV_ABS, ABS_RX, ABS_PRESSURE)
+	hasJoystickAxesOrButtons := numJoystickButtons > 0 || numJoystickAxes > 0
+
+	isMouse, isAbsMouse, isTouchpad, isTouchscreen := false, false, false, false
+	isTablet, isTabletPad, isJoystick := false, false, false
+
+	if hasAbsCoordinates {
+		switch {
+		case hasStylus || hasPen:
+			isTablet = true
+		case fingerButNoPen && !isDirect:
+			isTouchpad = true
+		case hasMouseButton:
+			// VMware's USB mouse has absolute axes, but no touch/pressure button
+			isAbsMouse = true
+		case hasTouch || isDirect:
+			isTouchscreen = true
+		case hasJoystickAxesOrButtons:
+			isJoystick = true
+		}
+	} else if hasJoystickAxesOrButtons {
+		isJoystick = true
+	}
+
+	if hasMTCoordinates {
+		switch {
+		case hasStylus || hasPen:
+			isTablet = true
+		case fingerButNoPen && !isDirect:
+			isTouchpad = true
+		case hasTouch || isDirect:
+			isTouchscreen = true
+		}
+	}
+
+	if isTablet && hasPadButtons {
+		isTabletPad = true
+	}
+
+	if hasPadButtons && hasWheel && !hasRelCoordinates {
+		isTablet = true
+		isTabletPad = true
+	}
+
+	if !isTablet && !isTouchpad && !isJoystick && hasMouseButton &&
+		(hasRelCoordinates || !hasAbsCoordinates) {
+		isMouse = true
+	}
+
+	// There is no such thing as an i2c mouse
+	if isMouse && bus == BUS_I2C {
+		isPointingStick = true
+	}
+
+	// Some keyboards have random joystick buttons set. A joystick may have
+	// one of the well-known keyboard keys, but probably not several.
+	if isJoystick {
+		wellKnownKeyboardKeys := []EvCode{
+			KEY_LEFTCTRL, KEY_CAPSLOCK, KEY_NUMLOCK, KEY_INSERT,
+			KEY_MUTE, KEY_CALC, KEY_FILE, KEY_MAIL, KEY_PLAYPAUSE,
+			KEY_BRIGHTNESSDOWN,
+		}
+
+		numWellKnownKeys := 0
+		for _, key := range wellKnownKeyboardKeys {
+			if s.has(EV_KEY, key) {
+				numWellKnownKeys++
+			}
+		}
+
+		if numWellKnownKeys >= 4 || numJoystickButtons+numJoystickAxes < 2 {
+			isJoystick = false
+		}
+
+		if hasWheel && hasPadButtons {
+			isJoystick = false
+		}
+	}
+
+	if isPointingStick {
+		class |= ClassPointingStick
+	}
+	if isMouse || isAbsMouse {
+		class |= ClassMouse
+	}
+	if isTouchpad {
+		class |= ClassTouchpad
+	}
+	if isTouchscreen {
+		class |= ClassTouchscreen
+	}
+	if isJoystick {
+		class |= ClassJoystick
+	}
+	if isTablet {
+		class |= ClassTablet
+	}
+	if isTabletPad {
+		class |= ClassTabletPad
+	}
+
+	return class
+}
+
+// classifyKeys follows test_key() in udev's input_id builtin
+func classifyKeys(s *capabilitySet) DeviceClass {
+	var class DeviceClass
+
+	if !s.types[EV_KEY] {
+		return 0
+	}
+
+	// only consider KEY_* here, not BTN_*
+	if s.count(EV_KEY, 0, BTN_MISC) > 0 ||
+		s.count(EV_KEY, KEY_OK, BTN_DPAD_UP) > 0 ||
+		s.count(EV_KEY, KEY_ALS_TOGGLE, BTN_TRIGGER_HAPPY) > 0 {
+		class |= ClassKey
+	}
+
+	// The first 32 bits are ESC, numbers, and Q to D. If all of them are
+	// present, consider it a full keyboard (KEY_RESERVED is not tested).
+	if s.count(EV_KEY, KEY_ESC, 32) == 31 {
+		class |= ClassKey | ClassKeyboard
+	}
+
+	return class
+}
+
+func classify(s *capabilitySet, bus uint16) DeviceClass {
+	pointer := classifyPointer(s, bus)
+	keys := classifyKeys(s)
+
+	class := pointer | keys
+
+	// Some devices have only a scroll wheel
+	if pointer == 0 && keys == 0 && s.types[EV_REL] &&
+		(s.has(EV_REL, REL_WHEEL) || s.has(EV_REL, REL_HWHEEL)) {
+		class |= ClassKey
+	}
+
+	if s.types[EV_SW] {
+		class |= ClassSwitch
+	}
+
+	return class
+}
@@ -0,0 +1,110 @@
+package evdev
+
+import (
+	"testing"
+)
+
+func Test_classify(t *testing.T) {
+	var keyboardKeys []EvCode
+	for code := EvCode(KEY_ESC); code <= KEY_D; code++ {
+		keyboardKeys = append(keyboardKeys, code)
+	}
+
+	tests := []struct {
+		name         string
+		capabilities map[EvType][]EvCode
+		props        []EvProp
+		bus          uint16
+		want         DeviceClass
+	}{
+		{
+			name: "keyboard",
+			capabilities: map[EvType][]EvCode{
+				EV_SYN: nil,
+				EV_KEY: keyboardKeys,
+				EV_LED: {LED_CAPSL},
+			},
+			want: ClassKey | ClassKeyboard,
+		},
+		{
+			name: "mouse",
+			capabilities: map[EvType][]EvCode{
+				EV_KEY: {BTN_LEFT, BTN_RIGHT, BTN_MIDDLE},
+				EV_REL: {REL_X, REL_Y, REL_WHEEL},
+			},
+			want: ClassMouse,
+		},
+		{
+			name: "i2c mouse is a pointing stick",
+			capabilities: map[EvType][]EvCode{
+				EV_KEY: {BTN_LEFT, BTN_RIGHT},
+				EV_REL: {REL_X, REL_Y},
+			},
+			bus:  BUS_I2C,
+			want: ClassMouse | ClassPointingStick,
+		},
+		{
+			name: "touchpad",
+			capabilities: map[EvType][]EvCode{
+				EV_KEY: {BTN_LEFT, BTN_TOOL_FINGER, BTN_TOUCH},
+				EV_ABS: {ABS_X, ABS_Y, ABS_MT_SLOT, ABS_MT_POSITION_X, ABS_MT_POSITION_Y},
+			},
+			props: []EvProp{INPUT_PROP_POINTER, INPUT_PROP_BUTTONPAD},
+			want:  ClassTouchpad,
+		},
+		{
+			name: "touchscreen",
+			capabilities: map[EvType][]EvCode{
+				EV_KEY: {BTN_TOUCH},
+				EV_ABS: {ABS_X, ABS_Y, ABS_MT_SLOT, ABS_MT_POSITION_X, ABS_MT_POSITION_Y},
+			},
+			props: []EvProp{INPUT_PROP_DIRECT},
+			want:  ClassTouchscreen,
+		},
+		{
+			name: "tablet",
+			capabilities: map[EvType][]EvCode{
+				EV_KEY: {BTN_TOOL_PEN, BTN_TOUCH, BTN_STYLUS},
+				EV_ABS: {ABS_X, ABS_Y, ABS_PRESSURE},
+			},
+			want: ClassTablet,
+		},
+		{
+			name: "gamepad",
+			capabilities: map[EvType][]EvCode{
+				EV_KEY: {BTN_SOUTH, BTN_EAST, BTN_NORTH, BTN_WEST, BTN_START, BTN_SELECT},
+				EV_ABS: {ABS_X, ABS_Y, ABS_RX, ABS_RY},
+			},
+			want: ClassJoystick,
+		},
+		{
+			name: "accelerometer",
+			capabilities: map[EvType][]EvCode{
+				EV_ABS: {ABS_X, ABS_Y, ABS_Z},
+			},
+			want: ClassAccelerometer,
+		},
+		{
+			name: "lid switch",
+			capabilities: map[EvType][]EvCode{
+				EV_SW: {SW_LID},
+			},
+			want: ClassSwitch,
+		},
+		{
+			name: "scroll wheel only",
+			capabilities: map[EvType][]EvCode{
+				EV_REL: {REL_WHEEL},
+			},
+			want: ClassKey,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classify(newCapabilitySet(tt.capabilities, tt.props), tt.bus)
+			if got != tt.want {
+				t.Errorf("classify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
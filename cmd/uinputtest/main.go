@@ -3,7 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
-	"syscall"
+	"strings"
 	"time"
 
 	"github.com/holoplot/go-evdev"
@@ -71,49 +71,54 @@ func createDevice() {
 	moveMouse(dev)
 }
 
-func moveMouse(dev *evdev.InputDevice) {
+func typeText(text string) {
+	kbd, err := evdev.NewVirtualKeyboard("fake-keyboard", evdev.LayoutUS)
+	if err != nil {
+		fmt.Printf("failed to create keyboard: %s\n", err.Error())
+		return
+	}
+	defer kbd.Close()
+
+	kbd.Delay = 10 * time.Millisecond
+
+	// give the system some time to pick up the new device
+	time.Sleep(time.Second)
+
+	fmt.Println("Typing...")
+	if err := kbd.Type(text); err != nil {
+		fmt.Printf("failed to type: %s\n", err.Error())
+		return
+	}
+
+	fmt.Println("Done!")
+}
+
+func moveMouse(dev *evdev.VirtualDevice) {
 	fmt.Println("Moving the mouse...")
 	for i := 0; i < 400; i++ {
 		time.Sleep(10 * time.Millisecond)
 
-		evTime := syscall.NsecToTimeval(int64(time.Now().Nanosecond()))
-
-		if i < 100 {
-			dev.WriteOne(&evdev.InputEvent{
-				Time:  evTime,
-				Type:  evdev.EV_REL,
-				Code:  evdev.REL_X,
-				Value: 2,
-			})
-		} else if i < 200 {
-			dev.WriteOne(&evdev.InputEvent{
-				Time:  evTime,
-				Type:  evdev.EV_REL,
-				Code:  evdev.REL_Y,
-				Value: 2,
-			})
-		} else if i < 300 {
-			dev.WriteOne(&evdev.InputEvent{
-				Time:  evTime,
-				Type:  evdev.EV_REL,
-				Code:  evdev.REL_X,
-				Value: -2,
-			})
-		} else {
-			dev.WriteOne(&evdev.InputEvent{
-				Time:  evTime,
-				Type:  evdev.EV_REL,
-				Code:  evdev.REL_Y,
-				Value: -2,
-			})
+		var err error
+
+		switch {
+		case i < 100:
+			err = dev.Emit(evdev.EV_REL, evdev.REL_X, 2)
+		case i < 200:
+			err = dev.Emit(evdev.EV_REL, evdev.REL_Y, 2)
+		case i < 300:
+			err = dev.Emit(evdev.EV_REL, evdev.REL_X, -2)
+		default:
+			err = dev.Emit(evdev.EV_REL, evdev.REL_Y, -2)
+		}
+
+		if err == nil {
+			err = dev.Sync()
 		}
 
-		dev.WriteOne(&evdev.InputEvent{
-			Time:  evTime,
-			Type:  evdev.EV_SYN,
-			Code:  evdev.SYN_REPORT,
-			Value: 0,
-		})
+		if err != nil {
+			fmt.Printf("failed to write events: %s\n", err.Error())
+			return
+		}
 	}
 
 	fmt.Println("Done!")
@@ -122,7 +127,8 @@ func moveMouse(dev *evdev.InputDevice) {
 func usage() {
 	fmt.Print("Create a new input device, or clone capabiliies from an existing one\n\n")
 	fmt.Printf("Usage: %s clone [input device]\n", os.Args[0])
-	fmt.Printf("       %s create\n\n", os.Args[0])
+	fmt.Printf("       %s create\n", os.Args[0])
+	fmt.Printf("       %s type [text]\n\n", os.Args[0])
 	fmt.Printf("Available devices:\n")
 
 	listDevices()
@@ -146,6 +152,14 @@ func main() {
 	case "create":
 		createDevice()
 
+	case "type":
+		if len(os.Args) < 3 {
+			usage()
+			return
+		}
+
+		typeText(strings.Join(os.Args[2:], " "))
+
 	default:
 		usage()
 	}
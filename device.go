@@ -2,9 +2,12 @@ package evdev
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"fmt"
+	"io"
 	"os"
+	"sync"
 	"syscall"
 )
 
@@ -14,6 +17,10 @@ import (
 type InputDevice struct {
 	file          *os.File
 	driverVersion int32
+	pending       []InputEvent
+
+	pollerMu sync.Mutex // guards poller and its users
+	poller   *epoller
 }
 
 // OpenWithFlags creates a new InputDevice from the given path. The input device
@@ -76,6 +83,17 @@ func OpenByName(name string) (*InputDevice, error) {
 // Close releases the resources held by an InputDevice. After calling this
 // function, the InputDevice is no longer operational.
 func (d *InputDevice) Close() error {
+	d.pollerMu.Lock()
+	if p := d.poller; p != nil {
+		// concurrent ReadContext calls return, the last one closes the poller
+		p.shutdown()
+		if p.users == 0 {
+			p.close()
+		}
+		d.poller = nil
+	}
+	d.pollerMu.Unlock()
+
 	return d.file.Close()
 }
 
@@ -240,6 +258,19 @@ func (d *InputDevice) AbsInfos() (map[EvCode]AbsInfo, error) {
 	return a, nil
 }
 
+// MTSlotValues returns the current value of the given ABS_MT_* axis for all
+// multitouch slots of the device, indexed by slot number.
+func (d *InputDevice) MTSlotValues(code EvCode) ([]int32, error) {
+	fd := d.file.Fd()
+
+	slotInfo, err := ioctlEVIOCGABS(fd, ABS_MT_SLOT)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get slot info: %v", err)
+	}
+
+	return ioctlEVIOCGMTSLOTS(fd, int(code), int(slotInfo.Maximum)+1)
+}
+
 // Grab grabs the device for exclusive access. No other process will receive
 // input events until the device instance is active.
 func (d *InputDevice) Grab() error {
@@ -260,6 +291,7 @@ func (d *InputDevice) Revoke() error {
 // This way it is possible to interrupt ReadOne call by closing the device.
 // Note: file.Fd() call will set file descriptor back to blocking mode so make sure your program
 // is not using any other method than ReadOne after NonBlock call.
+// Consider using ReadContext instead, which does not suffer from this limitation.
 func (d *InputDevice) NonBlock() error {
 	return syscall.SetNonblock(int(d.file.Fd()), true)
 }
@@ -267,6 +299,23 @@ func (d *InputDevice) NonBlock() error {
 // Read and returns a slice of InputEvents from the device.
 // It blocks until events has been received or an error has occurred.
 func (d *InputDevice) ReadSlice(eventSlice int) ([]InputEvent, error) {
+	if len(d.pending) > 0 {
+		count := len(d.pending)
+		if count > eventSlice {
+			count = eventSlice
+		}
+
+		events := make([]InputEvent, count)
+		copy(events, d.pending)
+		d.pending = d.pending[count:]
+
+		return events, nil
+	}
+
+	return d.readSlice(eventSlice)
+}
+
+func (d *InputDevice) readSlice(eventSlice int) ([]InputEvent, error) {
 	buffer := make([]byte, eventsize*eventSlice)
 
 	bytesRead, err := d.file.Read(buffer)
@@ -274,8 +323,12 @@ func (d *InputDevice) ReadSlice(eventSlice int) ([]InputEvent, error) {
 		return nil, err
 	}
 
+	return decodeEvents(buffer[:bytesRead])
+}
+
+func decodeEvents(buffer []byte) ([]InputEvent, error) {
 	// Calculate how many complete events we actually got
-	count := bytesRead / eventsize
+	count := len(buffer) / eventsize
 	if count == 0 {
 		return nil, nil // no complete event in this read
 	}
@@ -283,8 +336,8 @@ func (d *InputDevice) ReadSlice(eventSlice int) ([]InputEvent, error) {
 	// Create events slice dynamically
 	events := make([]InputEvent, count)
 
-	reader := bytes.NewReader(buffer[:bytesRead])
-	if err = binary.Read(reader, binary.LittleEndian, &events); err != nil {
+	reader := bytes.NewReader(buffer[:count*eventsize])
+	if err := binary.Read(reader, binary.LittleEndian, &events); err != nil {
 		return nil, err
 	}
 
@@ -296,6 +349,13 @@ func (d *InputDevice) ReadSlice(eventSlice int) ([]InputEvent, error) {
 func (d *InputDevice) ReadOne() (*InputEvent, error) {
 	event := InputEvent{}
 
+	if len(d.pending) > 0 {
+		event = d.pending[0]
+		d.pending = d.pending[1:]
+
+		return &event, nil
+	}
+
 	err := binary.Read(d.file, binary.LittleEndian, &event)
 	if err != nil {
 		return nil, err
@@ -304,6 +364,141 @@ func (d *InputDevice) ReadOne() (*InputEvent, error) {
 	return &event, nil
 }
 
+// ReadContext reads one InputEvent from the device. It blocks until an event
+// has been received, an error has occurred or the context is done, in which
+// case the context's error is returned. Unlike ReadOne, it can be interrupted
+// without closing the device, regardless of the blocking mode of the device.
+func (d *InputDevice) ReadContext(ctx context.Context) (*InputEvent, error) {
+	if len(d.pending) > 0 {
+		event := d.pending[0]
+		d.pending = d.pending[1:]
+
+		return &event, nil
+	}
+
+	events, err := d.readSliceContext(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return &events[0], nil
+}
+
+// acquirePoller returns the poller of the device, creating it if necessary.
+// It must be released with releasePoller.
+func (d *InputDevice) acquirePoller(conn syscall.RawConn) (*epoller, error) {
+	d.pollerMu.Lock()
+	defer d.pollerMu.Unlock()
+
+	if d.poller == nil {
+		p, err := newEpoller()
+		if err != nil {
+			return nil, err
+		}
+
+		err = conn.Control(func(fd uintptr) {
+			err = p.add(int(fd))
+		})
+		if err != nil {
+			p.close()
+			return nil, err
+		}
+
+		d.poller = p
+	}
+
+	d.poller.users++
+
+	return d.poller, nil
+}
+
+// releasePoller closes the poller if the device was closed while it was in use.
+func (d *InputDevice) releasePoller(p *epoller) {
+	d.pollerMu.Lock()
+	defer d.pollerMu.Unlock()
+
+	p.users--
+	if p.users == 0 && p != d.poller {
+		p.close()
+	}
+}
+
+func (d *InputDevice) readSliceContext(ctx context.Context, eventSlice int) ([]InputEvent, error) {
+	// SyscallConn gives access to the file descriptor without
+	// resetting it to blocking mode, unlike file.Fd()
+	conn, err := d.file.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := d.acquirePoller(conn)
+	if err != nil {
+		return nil, err
+	}
+	defer d.releasePoller(p)
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		select {
+		case <-ctx.Done():
+			_ = p.wake()
+		case <-stop:
+		}
+	}()
+
+	// the poller must not be woken up after it was released
+	defer func() {
+		close(stop)
+		<-done
+	}()
+
+	buffer := make([]byte, eventsize*eventSlice)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// Wait outside of conn, so that a concurrent Close is not blocked.
+		fds, err := p.wait(-1)
+		if err != nil {
+			return nil, err
+		}
+
+		var n int
+		var readErr error
+
+		err = conn.Read(func(fd uintptr) bool {
+			if len(fds) > 0 {
+				n, readErr = syscall.Read(int(fd), buffer)
+			}
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(fds) == 0 || readErr == syscall.EAGAIN || readErr == syscall.EINTR {
+			continue
+		}
+		if readErr != nil {
+			return nil, readErr
+		}
+		if n == 0 {
+			return nil, io.EOF
+		}
+
+		events, err := decodeEvents(buffer[:n])
+		if err != nil || len(events) > 0 {
+			return events, err
+		}
+	}
+}
+
 // WriteOne writes one InputEvent to the device.
 // Useful for controlling LEDs of the device
 func (d *InputDevice) WriteOne(event *InputEvent) error {
@@ -0,0 +1,152 @@
+package evdev
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+// Event type and codes used by uinput to request force-feedback effect
+// uploads and erasures, see linux/uinput.h.
+const (
+	evUinput   EvType = 0x0101
+	uiFFUpload EvCode = 1
+	uiFFErase  EvCode = 2
+)
+
+// uinputFFUpload mirrors struct uinput_ff_upload.
+type uinputFFUpload struct {
+	RequestID uint32
+	Retval    int32
+	Effect    ffEffect
+	Old       ffEffect
+}
+
+// uinputFFErase mirrors struct uinput_ff_erase.
+type uinputFFErase struct {
+	RequestID uint32
+	Retval    int32
+	EffectID  uint32
+}
+
+// FeedbackHandler handles the feedback that clients of a virtual device's
+// event node send to it, such as LED changes or force-feedback requests.
+// All callbacks are optional.
+type FeedbackHandler struct {
+	// OnEvent is called for EV_LED, EV_SND and EV_FF events, e.g. when the
+	// compositor toggles the CapsLock LED or a game plays an effect.
+	OnEvent func(event *InputEvent)
+	// OnUpload is called when a client uploads a force-feedback effect.
+	// effect.ID is the ID assigned to it. old is the effect being replaced,
+	// or nil for new effects. Returning an error makes the upload fail.
+	OnUpload func(effect, old *FFEffect) error
+	// OnErase is called when a client erases a force-feedback effect.
+	// Returning an error makes the erasure fail.
+	OnErase func(id int16) error
+}
+
+// feedbackRetval converts an error returned by a callback to the value
+// reported to the client.
+func feedbackRetval(err error) int32 {
+	if err == nil {
+		return 0
+	}
+
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		return -int32(errno)
+	}
+
+	return -int32(syscall.EINVAL)
+}
+
+// dispatch passes the upload request to the handler and records its result.
+func (u *uinputFFUpload) dispatch(handler *FeedbackHandler) {
+	if handler.OnUpload == nil {
+		return
+	}
+
+	var old *FFEffect
+	if u.Old.Type != 0 {
+		old = unmarshalFFEffect(&u.Old)
+	}
+
+	u.Retval = feedbackRetval(handler.OnUpload(unmarshalFFEffect(&u.Effect), old))
+}
+
+// dispatch passes the erase request to the handler and records its result.
+func (e *uinputFFErase) dispatch(handler *FeedbackHandler) {
+	if handler.OnErase != nil {
+		e.Retval = feedbackRetval(handler.OnErase(int16(e.EffectID)))
+	}
+}
+
+func (v *VirtualDevice) handleFFUpload(requestID int32, handler *FeedbackHandler) error {
+	upload := uinputFFUpload{RequestID: uint32(requestID)}
+
+	if err := ioctlUIBEGINFFUPLOAD(v.dev.file.Fd(), &upload); err != nil {
+		return fmt.Errorf("cannot begin effect upload: %v", err)
+	}
+
+	upload.dispatch(handler)
+
+	if err := ioctlUIENDFFUPLOAD(v.dev.file.Fd(), &upload); err != nil {
+		return fmt.Errorf("cannot end effect upload: %v", err)
+	}
+
+	return nil
+}
+
+func (v *VirtualDevice) handleFFErase(requestID int32, handler *FeedbackHandler) error {
+	erase := uinputFFErase{RequestID: uint32(requestID)}
+
+	if err := ioctlUIBEGINFFERASE(v.dev.file.Fd(), &erase); err != nil {
+		return fmt.Errorf("cannot begin effect erasure: %v", err)
+	}
+
+	erase.dispatch(handler)
+
+	if err := ioctlUIENDFFERASE(v.dev.file.Fd(), &erase); err != nil {
+		return fmt.Errorf("cannot end effect erasure: %v", err)
+	}
+
+	return nil
+}
+
+// HandleFeedback reads feedback from the device and dispatches it to the
+// handler until the context is done or an error occurs. Effect uploads and
+// erasures are acknowledged even if the corresponding callback is not set.
+// Clients uploading or erasing effects block until the request is handled,
+// so a device with EV_FF capabilities should always have a running
+// HandleFeedback.
+func (v *VirtualDevice) HandleFeedback(ctx context.Context, handler FeedbackHandler) error {
+	for {
+		if v.isClosed() {
+			return ErrVirtualDeviceClosed
+		}
+
+		event, err := v.dev.ReadContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		switch event.Type {
+		case evUinput:
+			switch event.Code {
+			case uiFFUpload:
+				err = v.handleFFUpload(event.Value, &handler)
+			case uiFFErase:
+				err = v.handleFFErase(event.Value, &handler)
+			}
+
+			if err != nil {
+				return err
+			}
+		case EV_LED, EV_SND, EV_FF:
+			if handler.OnEvent != nil {
+				handler.OnEvent(event)
+			}
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package evdev
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func Test_feedbackRetval(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int32
+	}{
+		{err: nil, want: 0},
+		{err: syscall.EBUSY, want: -int32(syscall.EBUSY)},
+		{err: errors.New("no motor"), want: -int32(syscall.EINVAL)},
+	}
+
+	for _, tt := range tests {
+		if got := feedbackRetval(tt.err); got != tt.want {
+			t.Errorf("feedbackRetval(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestFFUploadDispatch(t *testing.T) {
+	rumble := &FFEffect{ID: 2, Replay: FFReplay{Length: 100}, Data: &FFRumbleEffect{StrongMagnitude: 0x4000}}
+
+	marshal := func(effect *FFEffect) ffEffect {
+		ff := ffEffect{
+			Type:   effect.Data.effectType(),
+			ID:     effect.ID,
+			Replay: effect.Replay,
+		}
+		effect.Data.marshal(&ff)
+		return ff
+	}
+
+	tests := []struct {
+		name       string
+		old        *FFEffect
+		err        error
+		wantRetval int32
+	}{
+		{name: "new effect"},
+		{name: "replaced effect", old: &FFEffect{ID: 2, Data: &FFRumbleEffect{WeakMagnitude: 1}}},
+		{name: "rejected", err: syscall.ENOSPC, wantRetval: -int32(syscall.ENOSPC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upload := uinputFFUpload{Effect: marshal(rumble)}
+			if tt.old != nil {
+				upload.Old = marshal(tt.old)
+			}
+
+			called := false
+			upload.dispatch(&FeedbackHandler{
+				OnUpload: func(effect, old *FFEffect) error {
+					called = true
+
+					if !reflect.DeepEqual(effect, rumble) {
+						t.Errorf("effect = %+v, want %+v", effect, rumble)
+					}
+					if !reflect.DeepEqual(old, tt.old) {
+						t.Errorf("old = %+v, want %+v", old, tt.old)
+					}
+
+					return tt.err
+				},
+			})
+
+			if !called {
+				t.Error("OnUpload not called")
+			}
+
+			if upload.Retval != tt.wantRetval {
+				t.Errorf("Retval = %d, want %d", upload.Retval, tt.wantRetval)
+			}
+		})
+	}
+
+	// uploads are acknowledged without a callback
+	upload := uinputFFUpload{Effect: marshal(rumble)}
+	upload.dispatch(&FeedbackHandler{})
+
+	if upload.Retval != 0 {
+		t.Errorf("Retval without OnUpload = %d, want 0", upload.Retval)
+	}
+}
+
+func TestFFEraseDispatch(t *testing.T) {
+	erase := uinputFFErase{EffectID: 5}
+
+	var erased int16 = -1
+	erase.dispatch(&FeedbackHandler{
+		OnErase: func(id int16) error {
+			erased = id
+			return syscall.EBUSY
+		},
+	})
+
+	if erased != 5 {
+		t.Errorf("OnErase called with %d, want 5", erased)
+	}
+
+	if erase.Retval != -int32(syscall.EBUSY) {
+		t.Errorf("Retval = %d, want %d", erase.Retval, -int32(syscall.EBUSY))
+	}
+}
+
+// pipeFeedbackDevice returns a VirtualDevice whose feedback is read from a
+// pipe instead of /dev/uinput, and the write end of the pipe.
+func pipeFeedbackDevice(t *testing.T) (*VirtualDevice, func(events ...InputEvent)) {
+	d, w := pipeInputDevice(t)
+
+	return &VirtualDevice{dev: d}, func(events ...InputEvent) {
+		writeEvents(t, w, events...)
+	}
+}
+
+func TestHandleFeedbackOnEvent(t *testing.T) {
+	v, write := pipeFeedbackDevice(t)
+
+	want := []InputEvent{
+		{Type: EV_LED, Code: LED_CAPSL, Value: 1},
+		{Type: EV_SND, Code: SND_BELL, Value: 1},
+		{Type: EV_FF, Code: 0, Value: 1},
+	}
+
+	write(
+		want[0],
+		// not feedback
+		InputEvent{Type: EV_KEY, Code: KEY_A, Value: 1},
+		want[1],
+		InputEvent{Type: EV_SYN, Code: SYN_REPORT},
+		want[2],
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var got []InputEvent
+	err := v.HandleFeedback(ctx, FeedbackHandler{
+		OnEvent: func(event *InputEvent) {
+			got = append(got, *event)
+			if len(got) == len(want) {
+				cancel()
+			}
+		},
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("HandleFeedback() = %v, want %v", err, context.Canceled)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("OnEvent called with %v, want %v", got, want)
+	}
+}
+
+func TestHandleFeedbackFFRequests(t *testing.T) {
+	tests := []struct {
+		name string
+		code EvCode
+		want string
+	}{
+		{name: "upload", code: uiFFUpload, want: "cannot begin effect upload"},
+		{name: "erase", code: uiFFErase, want: "cannot begin effect erasure"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, write := pipeFeedbackDevice(t)
+
+			// a pipe does not support the uinput ioctls, but reaching them
+			// shows that the request was dispatched
+			write(InputEvent{Type: evUinput, Code: tt.code, Value: 1})
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			err := v.HandleFeedback(ctx, FeedbackHandler{
+				OnEvent: func(event *InputEvent) {
+					t.Errorf("OnEvent called with %v", event)
+				},
+			})
+
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("HandleFeedback() = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
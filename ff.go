@@ -0,0 +1,309 @@
+package evdev
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+)
+
+// FFTrigger describes what triggers a force-feedback effect.
+type FFTrigger struct {
+	Button   uint16 // number of the button triggering the effect
+	Interval uint16 // controls how soon the effect can be re-triggered
+}
+
+// FFReplay describes the scheduling of a force-feedback effect.
+type FFReplay struct {
+	Length uint16 // duration of the effect in milliseconds, 0 means infinite
+	Delay  uint16 // delay before the effect starts playing, in milliseconds
+}
+
+// FFEnvelope describes a generic force-feedback effect envelope.
+type FFEnvelope struct {
+	AttackLength uint16 // duration of the attack in milliseconds
+	AttackLevel  uint16 // level at the beginning of the attack
+	FadeLength   uint16 // duration of the fade in milliseconds
+	FadeLevel    uint16 // level at the end of the fade
+}
+
+// FFEffectData is implemented by all force-feedback effect types that can be
+// uploaded to a device: *FFRumbleEffect, *FFPeriodicEffect, *FFConstantEffect,
+// *FFRampEffect and *FFConditionEffect.
+type FFEffectData interface {
+	effectType() uint16
+	marshal(e *ffEffect)
+}
+
+// FFRumbleEffect describes a rumble effect. The strong motor is usually
+// the heavy, low-frequency one, the weak motor the light, high-frequency one.
+type FFRumbleEffect struct {
+	StrongMagnitude uint16
+	WeakMagnitude   uint16
+}
+
+// FFConstantEffect describes a constant force-feedback effect.
+type FFConstantEffect struct {
+	Level    int16
+	Envelope FFEnvelope
+}
+
+// FFRampEffect describes a ramp force-feedback effect.
+type FFRampEffect struct {
+	StartLevel int16
+	EndLevel   int16
+	Envelope   FFEnvelope
+}
+
+// FFCondition describes the parameters of a condition effect on one axis.
+type FFCondition struct {
+	RightSaturation uint16 // maximum level when joystick moved all way to the right
+	LeftSaturation  uint16 // same for the left side
+	RightCoeff      int16  // controls how fast the force grows when joystick moves to the right
+	LeftCoeff       int16  // same for the left side
+	Deadband        uint16 // size of the dead zone, where no force is produced
+	Center          int16  // position of the dead zone
+}
+
+// FFConditionEffect describes a condition force-feedback effect.
+// Type must be one of FF_SPRING, FF_FRICTION, FF_DAMPER or FF_INERTIA.
+// Axes holds the parameters for the X and the Y axis.
+type FFConditionEffect struct {
+	Type EvCode
+	Axes [2]FFCondition
+}
+
+// FFPeriodicEffect describes a periodic force-feedback effect.
+// Waveform must be one of FF_SQUARE, FF_TRIANGLE, FF_SINE, FF_SAW_UP,
+// FF_SAW_DOWN or FF_CUSTOM. CustomData is only used with FF_CUSTOM.
+type FFPeriodicEffect struct {
+	Waveform   EvCode
+	Period     uint16 // period of the wave in milliseconds
+	Magnitude  int16  // peak value
+	Offset     int16  // mean value of the wave (roughly)
+	Phase      uint16 // horizontal shift
+	Envelope   FFEnvelope
+	CustomData []int16
+}
+
+// FFEffect describes a force-feedback effect that can be uploaded to a device.
+// Set ID to -1 to upload a new effect, or to the ID of an already uploaded
+// effect to modify it. Use NewFFEffect to get an effect with ID set to -1.
+type FFEffect struct {
+	ID        int16
+	Direction uint16 // direction of the effect, 0x4000 = left, 0x8000 = up, 0xc000 = right
+	Trigger   FFTrigger
+	Replay    FFReplay
+	Data      FFEffectData
+}
+
+// NewFFEffect returns a new, not yet uploaded force-feedback effect with the
+// given effect data.
+func NewFFEffect(data FFEffectData) *FFEffect {
+	return &FFEffect{
+		ID:   -1,
+		Data: data,
+	}
+}
+
+// ffEffectUnionSize is the size of the union in struct ff_effect, which is
+// dominated by struct ff_periodic_effect and its trailing pointer.
+const ffEffectUnionSize = 24 + unsafe.Sizeof(uintptr(0))
+
+// ffEffect mirrors the kernel's struct ff_effect. U is declared as uintptr
+// words so that it has the pointer alignment of the union in C.
+type ffEffect struct {
+	Type      uint16
+	ID        int16
+	Direction uint16
+	Trigger   FFTrigger
+	Replay    FFReplay
+	U         [ffEffectUnionSize / unsafe.Sizeof(uintptr(0))]uintptr
+}
+
+// ffPeriodicEffect mirrors the kernel's struct ff_periodic_effect
+type ffPeriodicEffect struct {
+	Waveform   uint16
+	Period     uint16
+	Magnitude  int16
+	Offset     int16
+	Phase      uint16
+	Envelope   FFEnvelope
+	CustomLen  uint32
+	CustomData uintptr
+}
+
+func (e *FFRumbleEffect) effectType() uint16 {
+	return FF_RUMBLE
+}
+
+func (e *FFRumbleEffect) marshal(ff *ffEffect) {
+	*(*FFRumbleEffect)(unsafe.Pointer(&ff.U)) = *e
+}
+
+func (e *FFConstantEffect) effectType() uint16 {
+	return FF_CONSTANT
+}
+
+func (e *FFConstantEffect) marshal(ff *ffEffect) {
+	*(*FFConstantEffect)(unsafe.Pointer(&ff.U)) = *e
+}
+
+func (e *FFRampEffect) effectType() uint16 {
+	return FF_RAMP
+}
+
+func (e *FFRampEffect) marshal(ff *ffEffect) {
+	*(*FFRampEffect)(unsafe.Pointer(&ff.U)) = *e
+}
+
+func (e *FFConditionEffect) effectType() uint16 {
+	return uint16(e.Type)
+}
+
+func (e *FFConditionEffect) marshal(ff *ffEffect) {
+	*(*[2]FFCondition)(unsafe.Pointer(&ff.U)) = e.Axes
+}
+
+func (e *FFPeriodicEffect) effectType() uint16 {
+	return FF_PERIODIC
+}
+
+func (e *FFPeriodicEffect) marshal(ff *ffEffect) {
+	p := (*ffPeriodicEffect)(unsafe.Pointer(&ff.U))
+	p.Waveform = uint16(e.Waveform)
+	p.Period = e.Period
+	p.Magnitude = e.Magnitude
+	p.Offset = e.Offset
+	p.Phase = e.Phase
+	p.Envelope = e.Envelope
+
+	if len(e.CustomData) > 0 {
+		p.CustomLen = uint32(len(e.CustomData))
+		p.CustomData = uintptr(unsafe.Pointer(&e.CustomData[0]))
+	}
+}
+
+// unmarshalFFEffect converts an effect received from the kernel. Custom
+// periodic data is not copied, as it refers to memory of another process.
+func unmarshalFFEffect(ff *ffEffect) *FFEffect {
+	effect := &FFEffect{
+		ID:        ff.ID,
+		Direction: ff.Direction,
+		Trigger:   ff.Trigger,
+		Replay:    ff.Replay,
+	}
+
+	u := unsafe.Pointer(&ff.U)
+
+	switch EvCode(ff.Type) {
+	case FF_RUMBLE:
+		data := *(*FFRumbleEffect)(u)
+		effect.Data = &data
+	case FF_CONSTANT:
+		data := *(*FFConstantEffect)(u)
+		effect.Data = &data
+	case FF_RAMP:
+		data := *(*FFRampEffect)(u)
+		effect.Data = &data
+	case FF_SPRING, FF_FRICTION, FF_DAMPER, FF_INERTIA:
+		effect.Data = &FFConditionEffect{
+			Type: EvCode(ff.Type),
+			Axes: *(*[2]FFCondition)(u),
+		}
+	case FF_PERIODIC:
+		p := (*ffPeriodicEffect)(u)
+		effect.Data = &FFPeriodicEffect{
+			Waveform:  EvCode(p.Waveform),
+			Period:    p.Period,
+			Magnitude: p.Magnitude,
+			Offset:    p.Offset,
+			Phase:     p.Phase,
+			Envelope:  p.Envelope,
+		}
+	}
+
+	return effect
+}
+
+// UploadEffect uploads a force-feedback effect to the device. If effect.ID
+// is -1, a new effect is created and effect.ID is updated with the ID
+// assigned by the kernel. Otherwise, the existing effect with that ID is
+// modified.
+func (d *InputDevice) UploadEffect(effect *FFEffect) error {
+	if effect.Data == nil {
+		return fmt.Errorf("effect has no data")
+	}
+
+	ff := ffEffect{
+		Type:      effect.Data.effectType(),
+		ID:        effect.ID,
+		Direction: effect.Direction,
+		Trigger:   effect.Trigger,
+		Replay:    effect.Replay,
+	}
+	effect.Data.marshal(&ff)
+
+	err := ioctlEVIOCSFF(d.file.Fd(), &ff)
+	if p, ok := effect.Data.(*FFPeriodicEffect); ok {
+		// ff only holds the address of the custom data as uintptr
+		runtime.KeepAlive(p.CustomData)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot upload effect: %v", err)
+	}
+
+	effect.ID = ff.ID
+
+	return nil
+}
+
+// EraseEffect removes a previously uploaded force-feedback effect from the device.
+func (d *InputDevice) EraseEffect(id int16) error {
+	return ioctlEVIOCRMFF(d.file.Fd(), id)
+}
+
+// PlayEffect starts playing a previously uploaded force-feedback effect.
+// The effect is repeated count times.
+func (d *InputDevice) PlayEffect(id int16, count int32) error {
+	return d.WriteOne(&InputEvent{
+		Type:  EV_FF,
+		Code:  EvCode(id),
+		Value: count,
+	})
+}
+
+// StopEffect stops playing a force-feedback effect.
+func (d *InputDevice) StopEffect(id int16) error {
+	return d.PlayEffect(id, 0)
+}
+
+// SetGain sets the overall force-feedback gain of the device.
+// The gain ranges from 0 to 0xffff.
+func (d *InputDevice) SetGain(gain uint16) error {
+	return d.WriteOne(&InputEvent{
+		Type:  EV_FF,
+		Code:  FF_GAIN,
+		Value: int32(gain),
+	})
+}
+
+// SetAutocenter sets the autocenter strength of the device.
+// The strength ranges from 0 (disabled) to 0xffff.
+func (d *InputDevice) SetAutocenter(autocenter uint16) error {
+	return d.WriteOne(&InputEvent{
+		Type:  EV_FF,
+		Code:  FF_AUTOCENTER,
+		Value: int32(autocenter),
+	})
+}
+
+// MaxEffects returns the number of force-feedback effects the device can
+// keep in memory simultaneously.
+func (d *InputDevice) MaxEffects() (int, error) {
+	effects, err := ioctlEVIOCGEFFECTS(d.file.Fd())
+	if err != nil {
+		return 0, err
+	}
+
+	return int(effects), nil
+}
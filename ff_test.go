@@ -0,0 +1,76 @@
+package evdev
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+func TestFFEffectRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		data FFEffectData
+	}{
+		{name: "rumble", data: &FFRumbleEffect{StrongMagnitude: 0x8000, WeakMagnitude: 0x1234}},
+		{name: "constant", data: &FFConstantEffect{Level: -100, Envelope: FFEnvelope{AttackLength: 10, FadeLevel: 5}}},
+		{name: "ramp", data: &FFRampEffect{StartLevel: -5, EndLevel: 7}},
+		{name: "condition", data: &FFConditionEffect{Type: FF_SPRING, Axes: [2]FFCondition{{RightCoeff: 3}, {Center: -4}}}},
+		{name: "periodic", data: &FFPeriodicEffect{Waveform: FF_SINE, Period: 100, Magnitude: 200, Phase: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := &FFEffect{
+				ID:        3,
+				Direction: 0x4000,
+				Trigger:   FFTrigger{Button: 1, Interval: 2},
+				Replay:    FFReplay{Length: 500, Delay: 10},
+				Data:      tt.data,
+			}
+
+			ff := ffEffect{
+				Type:      want.Data.effectType(),
+				ID:        want.ID,
+				Direction: want.Direction,
+				Trigger:   want.Trigger,
+				Replay:    want.Replay,
+			}
+			want.Data.marshal(&ff)
+
+			if got := unmarshalFFEffect(&ff); !reflect.DeepEqual(got, want) {
+				t.Errorf("unmarshalFFEffect() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestUinputFFStructSizes(t *testing.T) {
+	if unsafe.Sizeof(uintptr(0)) != 8 {
+		t.Skip("sizes are checked on 64 bit architectures only")
+	}
+
+	if size := unsafe.Sizeof(uinputFFUpload{}); size != 104 {
+		t.Errorf("sizeof(uinputFFUpload) = %d, want 104", size)
+	}
+
+	if size := unsafe.Sizeof(uinputFFErase{}); size != 12 {
+		t.Errorf("sizeof(uinputFFErase) = %d, want 12", size)
+	}
+}
+
+func TestFFEffectLayout(t *testing.T) {
+	ptrSize := unsafe.Sizeof(uintptr(0))
+
+	var ff ffEffect
+	if offset := unsafe.Offsetof(ff.U); offset%ptrSize != 0 {
+		t.Errorf("offset of union = %d, want multiple of %d", offset, ptrSize)
+	}
+
+	var p ffPeriodicEffect
+	if offset := unsafe.Offsetof(ff.U) + unsafe.Offsetof(p.CustomData); offset%ptrSize != 0 {
+		t.Errorf("offset of custom data = %d, want multiple of %d", offset, ptrSize)
+	}
+
+	if size, want := unsafe.Sizeof(ff), 16+ffEffectUnionSize; size != want {
+		t.Errorf("sizeof(ffEffect) = %d, want %d", size, want)
+	}
+}
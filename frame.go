@@ -0,0 +1,107 @@
+package evdev
+
+import (
+	"context"
+	"syscall"
+)
+
+// FrameKind describes how a Frame was terminated.
+type FrameKind int
+
+const (
+	// FrameReport is a complete frame, terminated by SYN_REPORT.
+	FrameReport FrameKind = iota
+	// FrameDropped is a frame during which the kernel dropped events (SYN_DROPPED).
+	// Its events are incomplete and should be discarded, and the device state
+	// should be re-read.
+	FrameDropped
+)
+
+// frameReadSize is the number of events requested from the device
+// per read when assembling frames
+const frameReadSize = 64
+
+// Frame is a group of events that the kernel reports as one atomic change
+// of the device state. All events up to and including the terminating
+// SYN_REPORT are part of the frame.
+type Frame struct {
+	Kind   FrameKind
+	Time   syscall.Timeval // timestamp of the terminating SYN_REPORT
+	Events []InputEvent
+}
+
+// nextFrame looks for the first complete frame in events. It returns the frame
+// and the number of events it consumed, or nil if events holds no complete frame.
+// A frame containing SYN_DROPPED lasts until the next SYN_REPORT.
+func nextFrame(events []InputEvent) (*Frame, int) {
+	kind := FrameReport
+
+	for i, e := range events {
+		if e.Type != EV_SYN {
+			continue
+		}
+
+		switch e.Code {
+		case SYN_DROPPED:
+			kind = FrameDropped
+		case SYN_REPORT:
+			frame := &Frame{
+				Kind:   kind,
+				Time:   e.Time,
+				Events: make([]InputEvent, i+1),
+			}
+			copy(frame.Events, events)
+
+			return frame, i + 1
+		}
+	}
+
+	return nil, 0
+}
+
+// ReadFrame reads events from the device until a SYN_REPORT is received and
+// returns them as one Frame. It blocks until a frame is complete or an error
+// has occurred. Events read beyond the end of the frame are kept and returned
+// by subsequent calls to ReadFrame, ReadOne or ReadSlice.
+func (d *InputDevice) ReadFrame() (*Frame, error) {
+	return d.readFrame(func() ([]InputEvent, error) {
+		return d.readSlice(frameReadSize)
+	})
+}
+
+func (d *InputDevice) readFrame(read func() ([]InputEvent, error)) (*Frame, error) {
+	for {
+		if frame, n := nextFrame(d.pending); frame != nil {
+			d.pending = d.pending[n:]
+			return frame, nil
+		}
+
+		events, err := read()
+		if err != nil {
+			return nil, err
+		}
+
+		d.pending = append(d.pending, events...)
+	}
+}
+
+// ReadFrameContext is like ReadFrame, but can be interrupted through the given
+// context. See ReadContext for details.
+func (d *InputDevice) ReadFrameContext(ctx context.Context) (*Frame, error) {
+	return d.readFrame(func() ([]InputEvent, error) {
+		return d.readSliceContext(ctx, frameReadSize)
+	})
+}
+
+// ReadFrames reads frames from the device and sends them to the given channel
+// until an error occurs, which is then returned. The channel is not closed.
+func (d *InputDevice) ReadFrames(frames chan<- *Frame) error {
+	for {
+		frame, err := d.ReadFrame()
+		if err != nil {
+			return err
+		}
+
+		frames <- frame
+	}
+}
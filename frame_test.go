@@ -0,0 +1,176 @@
+package evdev
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func Test_nextFrame(t *testing.T) {
+	report := InputEvent{Time: syscall.Timeval{Sec: 1, Usec: 2}, Type: EV_SYN, Code: SYN_REPORT}
+	dropped := InputEvent{Type: EV_SYN, Code: SYN_DROPPED}
+	keyA := InputEvent{Type: EV_KEY, Code: KEY_A, Value: 1}
+	keyB := InputEvent{Type: EV_KEY, Code: KEY_B, Value: 1}
+
+	tests := []struct {
+		name   string
+		events []InputEvent
+		want   *Frame
+		wantN  int
+	}{
+		{
+			name:   "empty",
+			events: nil,
+			want:   nil,
+			wantN:  0,
+		},
+		{
+			name:   "incomplete",
+			events: []InputEvent{keyA, keyB},
+			want:   nil,
+			wantN:  0,
+		},
+		{
+			name:   "complete with trailing events",
+			events: []InputEvent{keyA, report, keyB},
+			want: &Frame{
+				Kind:   FrameReport,
+				Time:   report.Time,
+				Events: []InputEvent{keyA, report},
+			},
+			wantN: 2,
+		},
+		{
+			name:   "dropped",
+			events: []InputEvent{keyA, dropped, keyB, report, keyA},
+			want: &Frame{
+				Kind:   FrameDropped,
+				Time:   report.Time,
+				Events: []InputEvent{keyA, dropped, keyB, report},
+			},
+			wantN: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, n := nextFrame(tt.events)
+			if !reflect.DeepEqual(got, tt.want) || n != tt.wantN {
+				t.Errorf("nextFrame() = %v, %d, want %v, %d", got, n, tt.want, tt.wantN)
+			}
+		})
+	}
+}
+
+func TestReadFramePending(t *testing.T) {
+	d, w := pipeInputDevice(t)
+
+	key := func(code EvCode) InputEvent { return InputEvent{Type: EV_KEY, Code: code, Value: 1} }
+	syn := InputEvent{Type: EV_SYN, Code: SYN_REPORT}
+
+	// one read returns the first frame and the beginning of the next ones
+	writeEvents(t, w, key(KEY_A), syn, key(KEY_B), syn, key(KEY_C), key(KEY_D), syn, key(KEY_E))
+
+	frame, err := d.ReadFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []InputEvent{key(KEY_A), syn}; !reflect.DeepEqual(frame.Events, want) {
+		t.Errorf("ReadFrame() = %v, want %v", frame.Events, want)
+	}
+
+	event, err := d.ReadOne()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *event != key(KEY_B) {
+		t.Errorf("ReadOne() = %v, want %v", event, key(KEY_B))
+	}
+
+	event, err = d.ReadContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *event != syn {
+		t.Errorf("ReadContext() = %v, want %v", event, syn)
+	}
+
+	events, err := d.ReadSlice(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []InputEvent{key(KEY_C), key(KEY_D)}; !reflect.DeepEqual(events, want) {
+		t.Errorf("ReadSlice() = %v, want %v", events, want)
+	}
+
+	// the remaining pending events are returned before reading the device
+	writeEvents(t, w, key(KEY_F))
+
+	events, err = d.ReadSlice(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []InputEvent{syn, key(KEY_E)}; !reflect.DeepEqual(events, want) {
+		t.Errorf("ReadSlice() = %v, want %v", events, want)
+	}
+
+	events, err = d.ReadSlice(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []InputEvent{key(KEY_F)}; !reflect.DeepEqual(events, want) {
+		t.Errorf("ReadSlice() = %v, want %v", events, want)
+	}
+}
+
+func TestReadFrameSplit(t *testing.T) {
+	d, w := pipeInputDevice(t)
+
+	key := func(code EvCode) InputEvent { return InputEvent{Type: EV_KEY, Code: code, Value: 1} }
+	syn := InputEvent{Type: EV_SYN, Code: SYN_REPORT}
+
+	writeEvents(t, w, key(KEY_A))
+
+	rest := new(bytes.Buffer)
+	if err := binary.Write(rest, binary.LittleEndian, []InputEvent{key(KEY_B), syn, key(KEY_C)}); err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		// let ReadFrame read the first part on its own
+		time.Sleep(10 * time.Millisecond)
+
+		if _, err := w.Write(rest.Bytes()); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	frame, err := d.ReadFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []InputEvent{key(KEY_A), key(KEY_B), syn}; !reflect.DeepEqual(frame.Events, want) {
+		t.Errorf("ReadFrame() = %v, want %v", frame.Events, want)
+	}
+
+	writeEvents(t, w, syn)
+
+	frame, err = d.ReadFrameContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []InputEvent{key(KEY_C), syn}; !reflect.DeepEqual(frame.Events, want) {
+		t.Errorf("ReadFrameContext() = %v, want %v", frame.Events, want)
+	}
+}
@@ -0,0 +1,263 @@
+package evdev
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSequenceTimeout is the maximum time between two steps of a hotkey
+// sequence used if none is configured.
+const DefaultSequenceTimeout = time.Second
+
+// hotkeyModifiers are the side-independent modifier names that can be used
+// in hotkey strings. They match both the left and the right key.
+var hotkeyModifiers = map[string][]EvCode{
+	"CTRL":  {KEY_LEFTCTRL, KEY_RIGHTCTRL},
+	"SHIFT": {KEY_LEFTSHIFT, KEY_RIGHTSHIFT},
+	"ALT":   {KEY_LEFTALT, KEY_RIGHTALT},
+	"META":  {KEY_LEFTMETA, KEY_RIGHTMETA},
+}
+
+// hotkeyKey is one key of a combination, which is satisfied by any of its codes.
+type hotkeyKey []EvCode
+
+// hotkeyCombo is a set of keys that must be pressed at the same time.
+type hotkeyCombo []hotkeyKey
+
+func parseHotkeyKey(name string) (hotkeyKey, error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+
+	if codes, ok := hotkeyModifiers[name]; ok {
+		return codes, nil
+	}
+
+	for _, n := range []string{name, "KEY_" + name} {
+		if code, ok := KEYFromString[n]; ok {
+			return hotkeyKey{code}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown key %q", name)
+}
+
+// parseHotkey parses a hotkey string. Keys of a combination are separated by
+// "+", the combinations of a sequence by ",".
+func parseHotkey(s string) ([]hotkeyCombo, error) {
+	var sequence []hotkeyCombo
+
+	for _, step := range strings.Split(s, ",") {
+		var combo hotkeyCombo
+
+		for _, name := range strings.Split(step, "+") {
+			key, err := parseHotkeyKey(name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid hotkey %q: %v", s, err)
+			}
+			combo = append(combo, key)
+		}
+
+		sequence = append(sequence, combo)
+	}
+
+	return sequence, nil
+}
+
+func (k hotkeyKey) has(code EvCode) bool {
+	for _, c := range k {
+		if c == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// match checks the pressed keys against the combination. It returns whether
+// every pressed key is part of the combination, and whether all keys of the
+// combination are pressed.
+func (c hotkeyCombo) match(pressed map[EvCode]int) (partial bool, complete bool) {
+	for code := range pressed {
+		found := false
+		for _, key := range c {
+			if key.has(code) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false, false
+		}
+	}
+
+	for _, key := range c {
+		found := false
+		for _, code := range key {
+			if pressed[code] > 0 {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return true, false
+		}
+	}
+
+	return true, true
+}
+
+type hotkey struct {
+	sequence  []hotkeyCombo
+	duration  time.Duration
+	callback  func()
+	step      int
+	stepTime  time.Time
+	longPress *time.Timer
+}
+
+// HotkeyMatcher tracks the keys pressed on one or more devices and calls
+// callbacks when registered key combinations, sequences or long-presses occur.
+//
+// Hotkeys are given as strings of key names as in KEYFromString, with or
+// without the KEY_ prefix. Keys that must be pressed at the same time are
+// separated by "+", e.g. "KEY_LEFTCTRL+KEY_LEFTALT+KEY_T". The names CTRL,
+// SHIFT, ALT and META match both the left and the right modifier.
+// A combination matches only if no other keys are pressed.
+type HotkeyMatcher struct {
+	// SequenceTimeout is the maximum time between two steps of a sequence.
+	SequenceTimeout time.Duration
+
+	mu      sync.Mutex
+	pressed map[EvCode]int
+	hotkeys []*hotkey
+}
+
+// NewHotkeyMatcher creates a new HotkeyMatcher without any hotkeys.
+func NewHotkeyMatcher() *HotkeyMatcher {
+	return &HotkeyMatcher{
+		SequenceTimeout: DefaultSequenceTimeout,
+		pressed:         make(map[EvCode]int),
+	}
+}
+
+// Add registers a callback for a key combination or sequence. The steps of a
+// sequence (leader keys) are separated by ",", e.g. "CTRL+KEY_X, CTRL+KEY_F".
+func (m *HotkeyMatcher) Add(s string, callback func()) error {
+	sequence, err := parseHotkey(s)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.hotkeys = append(m.hotkeys, &hotkey{sequence: sequence, callback: callback})
+
+	return nil
+}
+
+// AddLongPress registers a callback for a key combination that is called once
+// the combination has been held for the given duration.
+func (m *HotkeyMatcher) AddLongPress(s string, duration time.Duration, callback func()) error {
+	sequence, err := parseHotkey(s)
+	if err != nil {
+		return err
+	}
+
+	if len(sequence) != 1 {
+		return fmt.Errorf("invalid long-press hotkey %q: sequences are not supported", s)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.hotkeys = append(m.hotkeys, &hotkey{sequence: sequence, duration: duration, callback: callback})
+
+	return nil
+}
+
+// Pressed returns the keys that are currently pressed on any device.
+func (m *HotkeyMatcher) Pressed() []EvCode {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var codes []EvCode
+	for code := range m.pressed {
+		codes = append(codes, code)
+	}
+
+	return sortCodes(codes)
+}
+
+// HandleEvent feeds one event into the matcher. Callbacks of matching hotkeys
+// are called before HandleEvent returns, except for long-presses, which are
+// called from a separate goroutine.
+func (m *HotkeyMatcher) HandleEvent(event *InputEvent) {
+	if event.Type != EV_KEY || event.Value == 2 {
+		return
+	}
+
+	var callbacks []func()
+
+	m.mu.Lock()
+
+	if event.Value == 0 {
+		if m.pressed[event.Code] > 1 {
+			m.pressed[event.Code]--
+		} else {
+			delete(m.pressed, event.Code)
+		}
+	} else {
+		m.pressed[event.Code]++
+	}
+
+	now := timevalTime(event.Time)
+
+	for _, h := range m.hotkeys {
+		if h.longPress != nil {
+			h.longPress.Stop()
+			h.longPress = nil
+		}
+
+		if event.Value == 0 {
+			continue
+		}
+
+		if h.step > 0 && now.Sub(h.stepTime) > m.SequenceTimeout {
+			h.step = 0
+		}
+
+		partial, complete := h.sequence[h.step].match(m.pressed)
+
+		if !partial && h.step > 0 {
+			// start over, the key may begin the sequence anew
+			h.step = 0
+			partial, complete = h.sequence[0].match(m.pressed)
+		}
+
+		switch {
+		case complete && h.step == len(h.sequence)-1:
+			h.step = 0
+
+			if h.duration > 0 {
+				h.longPress = time.AfterFunc(h.duration, h.callback)
+			} else {
+				callbacks = append(callbacks, h.callback)
+			}
+		case complete:
+			h.step++
+			h.stepTime = now
+		case !partial:
+			h.step = 0
+		}
+	}
+
+	m.mu.Unlock()
+
+	for _, callback := range callbacks {
+		callback()
+	}
+}
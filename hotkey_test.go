@@ -0,0 +1,162 @@
+package evdev
+
+import (
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestParseHotkey(t *testing.T) {
+	tests := []struct {
+		input   string
+		steps   int
+		keys    int
+		wantErr bool
+	}{
+		{input: "KEY_LEFTCTRL+KEY_LEFTALT+KEY_T", steps: 1, keys: 3},
+		{input: "ctrl + alt + t", steps: 1, keys: 3},
+		{input: "KEY_SPACE, KEY_A", steps: 2, keys: 1},
+		{input: "KEY_LEFTCTRL+", wantErr: true},
+		{input: "KEY_FOO", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseHotkey(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseHotkey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if len(got) != tt.steps || len(got[0]) != tt.keys {
+				t.Errorf("parseHotkey() = %v, want %d steps of %d keys", got, tt.steps, tt.keys)
+			}
+		})
+	}
+}
+
+func TestHotkeyMatcher(t *testing.T) {
+	key := func(ms int64, code EvCode, value int32) InputEvent {
+		return keyEvent(syscall.NsecToTimeval(ms*int64(time.Millisecond)), code, value)
+	}
+
+	tests := []struct {
+		name   string
+		hotkey string
+		input  []InputEvent
+		want   int
+	}{
+		{
+			name:   "combo",
+			hotkey: "KEY_LEFTCTRL+KEY_LEFTALT+KEY_T",
+			input: []InputEvent{
+				key(0, KEY_LEFTCTRL, 1),
+				key(10, KEY_LEFTALT, 1),
+				key(20, KEY_T, 1),
+				key(30, KEY_T, 2),
+				key(40, KEY_T, 0),
+				key(50, KEY_T, 1),
+			},
+			want: 2,
+		},
+		{
+			name:   "either side",
+			hotkey: "CTRL+KEY_T",
+			input: []InputEvent{
+				key(0, KEY_RIGHTCTRL, 1),
+				key(10, KEY_T, 1),
+			},
+			want: 1,
+		},
+		{
+			name:   "extra modifier",
+			hotkey: "CTRL+KEY_T",
+			input: []InputEvent{
+				key(0, KEY_LEFTCTRL, 1),
+				key(10, KEY_LEFTSHIFT, 1),
+				key(20, KEY_T, 1),
+			},
+			want: 0,
+		},
+		{
+			name:   "sequence",
+			hotkey: "CTRL+KEY_X, CTRL+KEY_F",
+			input: []InputEvent{
+				key(0, KEY_LEFTCTRL, 1),
+				key(10, KEY_X, 1),
+				key(20, KEY_X, 0),
+				key(30, KEY_F, 1),
+			},
+			want: 1,
+		},
+		{
+			name:   "interrupted sequence",
+			hotkey: "KEY_SPACE, KEY_A",
+			input: []InputEvent{
+				key(0, KEY_SPACE, 1),
+				key(10, KEY_SPACE, 0),
+				key(20, KEY_B, 1),
+				key(30, KEY_B, 0),
+				key(40, KEY_A, 1),
+			},
+			want: 0,
+		},
+		{
+			name:   "sequence timeout",
+			hotkey: "KEY_SPACE, KEY_A",
+			input: []InputEvent{
+				key(0, KEY_SPACE, 1),
+				key(10, KEY_SPACE, 0),
+				key(2000, KEY_A, 1),
+			},
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewHotkeyMatcher()
+
+			got := 0
+			if err := m.Add(tt.hotkey, func() { got++ }); err != nil {
+				t.Fatal(err)
+			}
+
+			for i := range tt.input {
+				m.HandleEvent(&tt.input[i])
+			}
+
+			if got != tt.want {
+				t.Errorf("callback called %d times, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHotkeyMatcherLongPress(t *testing.T) {
+	m := NewHotkeyMatcher()
+
+	fired := make(chan struct{}, 1)
+	if err := m.AddLongPress("KEY_ESC", 10*time.Millisecond, func() { fired <- struct{}{} }); err != nil {
+		t.Fatal(err)
+	}
+
+	// released too early
+	m.HandleEvent(&InputEvent{Type: EV_KEY, Code: KEY_ESC, Value: 1})
+	m.HandleEvent(&InputEvent{Type: EV_KEY, Code: KEY_ESC, Value: 0})
+
+	select {
+	case <-fired:
+		t.Fatal("long-press fired after release")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	m.HandleEvent(&InputEvent{Type: EV_KEY, Code: KEY_ESC, Value: 1})
+
+	select {
+	case <-fired:
+	case <-time.After(time.Second):
+		t.Fatal("long-press did not fire")
+	}
+}
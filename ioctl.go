@@ -1,7 +1,6 @@
 package evdev
 
 import (
-	"errors"
 	"fmt"
 	"strings"
 	"syscall"
@@ -39,7 +38,16 @@ func ioctlMakeCode(dir, typ, nr int, size uintptr) uint32 {
 func doIoctl(fd uintptr, code uint32, ptr unsafe.Pointer) error {
 	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, uintptr(code), uintptr(ptr))
 	if errno != 0 {
-		return errors.New(errno.Error())
+		return errno
+	}
+
+	return nil
+}
+
+func doIoctlValue(fd uintptr, code uint32, value uintptr) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, uintptr(code), value)
+	if errno != 0 {
+		return errno
 	}
 
 	return nil
@@ -111,6 +119,15 @@ func ioctlEVIOCGPROP(fd uintptr) ([]byte, error) {
 	return bits[:], err
 }
 
+func ioctlEVIOCGMTSLOTS(fd uintptr, abs int, numSlots int) ([]int32, error) {
+	// struct input_mt_request_layout: the code followed by one value per slot
+	values := make([]int32, numSlots+1)
+	values[0] = int32(abs)
+	code := ioctlMakeCode(ioctlDirRead, 'E', 0x0a, uintptr(len(values))*unsafe.Sizeof(values[0]))
+	err := doIoctl(fd, code, unsafe.Pointer(&values[0]))
+	return values[1:], err
+}
+
 func ioctlEVIOCGKEY(fd uintptr) ([]byte, error) {
 	bits := [KEY_MAX]byte{}
 	code := ioctlMakeCode(ioctlDirRead, 'E', 0x18, unsafe.Sizeof(bits))
@@ -189,6 +206,24 @@ func ioctlEVIOCSABS(fd uintptr, abs int, info AbsInfo) error {
 	return doIoctl(fd, code, unsafe.Pointer(&info))
 }
 
+func ioctlEVIOCSFF(fd uintptr, effect *ffEffect) error {
+	code := ioctlMakeCode(ioctlDirWrite, 'E', 0x80, unsafe.Sizeof(*effect))
+	return doIoctl(fd, code, unsafe.Pointer(effect))
+}
+
+func ioctlEVIOCRMFF(fd uintptr, id int16) error {
+	var p int32
+	code := ioctlMakeCode(ioctlDirWrite, 'E', 0x81, unsafe.Sizeof(p))
+	return doIoctlValue(fd, code, uintptr(id))
+}
+
+func ioctlEVIOCGEFFECTS(fd uintptr) (int32, error) {
+	effects := int32(0)
+	code := ioctlMakeCode(ioctlDirRead, 'E', 0x84, unsafe.Sizeof(effects))
+	err := doIoctl(fd, code, unsafe.Pointer(&effects))
+	return effects, err
+}
+
 func ioctlEVIOCGRAB(fd uintptr, p int32) error {
 	code := ioctlMakeCode(ioctlDirWrite, 'E', 0x90, unsafe.Sizeof(p))
 	if p != 0 {
@@ -206,61 +241,61 @@ func ioctlEVIOCREVOKE(fd uintptr) error {
 func ioctlUISETEVBIT(fd uintptr, ev uintptr) error {
 	var p int32
 	code := ioctlMakeCode(ioctlDirWrite, 'U', 100, unsafe.Sizeof(p))
-	return doIoctl(fd, code, unsafe.Pointer(ev))
+	return doIoctlValue(fd, code, ev)
 }
 
 func ioctlUISETKEYBIT(fd uintptr, key uintptr) error {
 	var p int32
 	code := ioctlMakeCode(ioctlDirWrite, 'U', 101, unsafe.Sizeof(p))
-	return doIoctl(fd, code, unsafe.Pointer(key))
+	return doIoctlValue(fd, code, key)
 }
 
 func ioctlUISETRELBIT(fd uintptr, rel uintptr) error {
 	var p int32
 	code := ioctlMakeCode(ioctlDirWrite, 'U', 102, unsafe.Sizeof(p))
-	return doIoctl(fd, code, unsafe.Pointer(rel))
+	return doIoctlValue(fd, code, rel)
 }
 
 func ioctlUISETABSBIT(fd uintptr, abs uintptr) error {
 	var p int32
 	code := ioctlMakeCode(ioctlDirWrite, 'U', 103, unsafe.Sizeof(p))
-	return doIoctl(fd, code, unsafe.Pointer(abs))
+	return doIoctlValue(fd, code, abs)
 }
 
 func ioctlUISETMSCBIT(fd uintptr, msc uintptr) error {
 	var p int32
 	code := ioctlMakeCode(ioctlDirWrite, 'U', 104, unsafe.Sizeof(p))
-	return doIoctl(fd, code, unsafe.Pointer(msc))
+	return doIoctlValue(fd, code, msc)
 }
 
 func ioctlUISETLEDBIT(fd uintptr, led uintptr) error {
 	var p int32
 	code := ioctlMakeCode(ioctlDirWrite, 'U', 105, unsafe.Sizeof(p))
-	return doIoctl(fd, code, unsafe.Pointer(led))
+	return doIoctlValue(fd, code, led)
 }
 
 func ioctlUISETSNDBIT(fd uintptr, snd uintptr) error {
 	var p int32
 	code := ioctlMakeCode(ioctlDirWrite, 'U', 106, unsafe.Sizeof(p))
-	return doIoctl(fd, code, unsafe.Pointer(snd))
+	return doIoctlValue(fd, code, snd)
 }
 
 func ioctlUISETFFBIT(fd uintptr, fe uintptr) error {
 	var p int32
 	code := ioctlMakeCode(ioctlDirWrite, 'U', 107, unsafe.Sizeof(p))
-	return doIoctl(fd, code, unsafe.Pointer(fe))
+	return doIoctlValue(fd, code, fe)
 }
 
 func ioctlUISETSWBIT(fd uintptr, sw uintptr) error {
 	var p int32
 	code := ioctlMakeCode(ioctlDirWrite, 'U', 109, unsafe.Sizeof(p))
-	return doIoctl(fd, code, unsafe.Pointer(sw))
+	return doIoctlValue(fd, code, sw)
 }
 
 func ioctlUISETPROPBIT(fd uintptr, prop uintptr) error {
 	var p int32
 	code := ioctlMakeCode(ioctlDirWrite, 'U', 110, unsafe.Sizeof(p))
-	return doIoctl(fd, code, unsafe.Pointer(prop))
+	return doIoctlValue(fd, code, prop)
 }
 
 func ioctlUIDEVCREATE(fd uintptr) error {
@@ -272,3 +307,53 @@ func ioctlUIDEVDESTROY(fd uintptr) error {
 	code := ioctlMakeCode(ioctlDirNone, 'U', 2, 0)
 	return doIoctl(fd, code, nil)
 }
+
+func ioctlUIDEVSETUP(fd uintptr, setup *uinputSetup) error {
+	code := ioctlMakeCode(ioctlDirWrite, 'U', 3, unsafe.Sizeof(*setup))
+	return doIoctl(fd, code, unsafe.Pointer(setup))
+}
+
+func ioctlUIABSSETUP(fd uintptr, setup *uinputAbsSetup) error {
+	code := ioctlMakeCode(ioctlDirWrite, 'U', 4, unsafe.Sizeof(*setup))
+	return doIoctl(fd, code, unsafe.Pointer(setup))
+}
+
+func ioctlUIGETVERSION(fd uintptr) (uint32, error) {
+	var version uint32
+	code := ioctlMakeCode(ioctlDirRead, 'U', 45, unsafe.Sizeof(version))
+	err := doIoctl(fd, code, unsafe.Pointer(&version))
+	return version, err
+}
+
+func ioctlUISETPHYS(fd uintptr, phys string) error {
+	b := append([]byte(phys), 0)
+	code := ioctlMakeCode(ioctlDirWrite, 'U', 108, unsafe.Sizeof(uintptr(0)))
+	return doIoctl(fd, code, unsafe.Pointer(&b[0]))
+}
+
+func ioctlUIBEGINFFUPLOAD(fd uintptr, upload *uinputFFUpload) error {
+	code := ioctlMakeCode(ioctlDirRead|ioctlDirWrite, 'U', 200, unsafe.Sizeof(*upload))
+	return doIoctl(fd, code, unsafe.Pointer(upload))
+}
+
+func ioctlUIENDFFUPLOAD(fd uintptr, upload *uinputFFUpload) error {
+	code := ioctlMakeCode(ioctlDirWrite, 'U', 201, unsafe.Sizeof(*upload))
+	return doIoctl(fd, code, unsafe.Pointer(upload))
+}
+
+func ioctlUIBEGINFFERASE(fd uintptr, erase *uinputFFErase) error {
+	code := ioctlMakeCode(ioctlDirRead|ioctlDirWrite, 'U', 202, unsafe.Sizeof(*erase))
+	return doIoctl(fd, code, unsafe.Pointer(erase))
+}
+
+func ioctlUIENDFFERASE(fd uintptr, erase *uinputFFErase) error {
+	code := ioctlMakeCode(ioctlDirWrite, 'U', 203, unsafe.Sizeof(*erase))
+	return doIoctl(fd, code, unsafe.Pointer(erase))
+}
+
+func ioctlUIGETSYSNAME(fd uintptr) (string, error) {
+	str := [256]byte{}
+	code := ioctlMakeCode(ioctlDirRead, 'U', 44, unsafe.Sizeof(str))
+	err := doIoctl(fd, code, unsafe.Pointer(&str))
+	return trimNull(string(str[:])), err
+}
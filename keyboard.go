@@ -0,0 +1,127 @@
+package evdev
+
+import (
+	"fmt"
+	"time"
+)
+
+// VirtualKeyboard is a virtual keyboard device created via uinput that can
+// type text and key chords.
+type VirtualKeyboard struct {
+	virtualDevice
+
+	// Delay is the time to wait after each key event.
+	Delay time.Duration
+
+	layout *KeyboardLayout
+}
+
+// NewVirtualKeyboard creates a virtual keyboard supporting all standard keys
+// and the NumLock, CapsLock and ScrollLock LEDs, whose state can be followed
+// with HandleFeedback on the underlying device. The layout is used to map
+// text to keys and must match the layout configured for the device in the
+// system that receives the events.
+func NewVirtualKeyboard(name string, layout *KeyboardLayout) (*VirtualKeyboard, error) {
+	var keys []EvCode
+	for code := EvCode(KEY_ESC); code <= KEY_MICMUTE; code++ {
+		keys = append(keys, code)
+	}
+
+	dev, err := CreateDevice(name, InputID{BusType: BUS_VIRTUAL}, map[EvType][]EvCode{
+		EV_KEY: keys,
+		EV_LED: {LED_NUML, LED_CAPSL, LED_SCROLLL},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &VirtualKeyboard{
+		virtualDevice: virtualDevice{dev: dev},
+		layout:        layout,
+	}, nil
+}
+
+// write emits a key event in a frame of its own and waits for the configured delay.
+func (k *VirtualKeyboard) write(code EvCode, value int32) error {
+	if err := k.button(code, value); err != nil {
+		return err
+	}
+
+	if k.Delay > 0 {
+		time.Sleep(k.Delay)
+	}
+
+	return nil
+}
+
+// Press presses a key.
+func (k *VirtualKeyboard) Press(code EvCode) error {
+	return k.write(code, 1)
+}
+
+// Release releases a key.
+func (k *VirtualKeyboard) Release(code EvCode) error {
+	return k.write(code, 0)
+}
+
+// Chord presses the given keys in order and releases them in reverse order,
+// e.g. Chord(KEY_LEFTCTRL, KEY_C).
+func (k *VirtualKeyboard) Chord(codes ...EvCode) error {
+	for i, code := range codes {
+		if err := k.Press(code); err != nil {
+			// do not leave keys stuck
+			for j := i - 1; j >= 0; j-- {
+				_ = k.Release(codes[j])
+			}
+			return err
+		}
+	}
+
+	for i := len(codes) - 1; i >= 0; i-- {
+		if err := k.Release(codes[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// chord returns the keys to press for the stroke, modifiers first.
+func (s layoutStroke) chord() []EvCode {
+	var chord []EvCode
+	if s.shift {
+		chord = append(chord, KEY_LEFTSHIFT)
+	}
+	if s.altGr {
+		chord = append(chord, KEY_RIGHTALT)
+	}
+
+	return append(chord, s.code)
+}
+
+// Type types the given text using the keyboard's layout. Characters without
+// a key of their own are composed with dead keys if possible. CapsLock is
+// assumed to be off. If the text contains a character that cannot be typed,
+// an error is returned and nothing is typed.
+func (k *VirtualKeyboard) Type(text string) error {
+	var chords [][]EvCode
+
+	for _, r := range text {
+		strokes, ok := k.layout.strokes(r)
+		if !ok {
+			return fmt.Errorf("cannot type %q with keyboard layout %q", r, k.layout.Name)
+		}
+
+		for _, s := range strokes {
+			chords = append(chords, s.chord())
+		}
+	}
+
+	for _, chord := range chords {
+		if err := k.Chord(chord...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
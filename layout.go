@@ -0,0 +1,447 @@
+package evdev
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// keysym is a symbol produced by a key. A zero rune means no symbol.
+// Dead keys carry the spacing form of their accent.
+type keysym struct {
+	r    rune
+	dead bool
+}
+
+// Indexes into layoutKey.levels.
+const (
+	levelShift = 1 << iota
+	levelAltGr
+)
+
+type layoutKey struct {
+	levels [4]keysym
+	caps   bool // affected by CapsLock
+}
+
+// layoutStroke is a key press with modifiers.
+type layoutStroke struct {
+	code  EvCode
+	shift bool
+	altGr bool
+}
+
+// KeyboardLayout maps key codes to the symbols they produce, depending on the
+// state of Shift and AltGr.
+type KeyboardLayout struct {
+	Name string
+	keys map[EvCode]layoutKey
+
+	// reverse mappings
+	runes map[rune]layoutStroke
+	dead  map[rune]layoutStroke
+}
+
+var deadKeys = map[string]rune{
+	"dead_grave":      '`',
+	"dead_acute":      '´',
+	"dead_circumflex": '^',
+	"dead_tilde":      '~',
+	"dead_diaeresis":  '¨',
+	"dead_cedilla":    '¸',
+}
+
+// deadCompose lists pairs of base and composed characters for each dead key.
+var deadCompose = map[rune]string{
+	'`': "aàeèiìoòuùAÀEÈIÌOÒUÙ",
+	'´': "aáeéiíoóuúyýcćnńAÁEÉIÍOÓUÚYÝCĆNŃ",
+	'^': "aâeêiîoôuûAÂEÊIÎOÔUÛ",
+	'~': "aãnñoõAÃNÑOÕ",
+	'¨': "aäeëiïoöuüyÿAÄEËIÏOÖUÜ",
+	'¸': "cçCÇ",
+}
+
+func composeDead(accent, base rune) (rune, bool) {
+	runes := []rune(deadCompose[accent])
+
+	for i := 0; i+1 < len(runes); i += 2 {
+		if runes[i] == base {
+			return runes[i+1], true
+		}
+	}
+
+	return 0, false
+}
+
+func parseKeysym(s string) (keysym, error) {
+	if s == "none" {
+		return keysym{}, nil
+	}
+
+	if accent, ok := deadKeys[s]; ok {
+		return keysym{r: accent, dead: true}, nil
+	}
+
+	if strings.HasPrefix(s, "U+") && len(s) > 2 {
+		v, err := strconv.ParseUint(s[2:], 16, 32)
+		if err != nil {
+			return keysym{}, fmt.Errorf("invalid code point %q", s)
+		}
+		return keysym{r: rune(v)}, nil
+	}
+
+	if utf8.RuneCountInString(s) == 1 {
+		r, _ := utf8.DecodeRuneInString(s)
+		return keysym{r: r}, nil
+	}
+
+	return keysym{}, fmt.Errorf("invalid symbol %q", s)
+}
+
+// commonLayout is the base of every layout.
+const commonLayout = `
+KEY_SPACE U+0020
+KEY_TAB U+0009
+KEY_ENTER U+000A
+KEY_BACKSPACE U+0008
+KEY_KPENTER U+000A
+KEY_KP0 0
+KEY_KP1 1
+KEY_KP2 2
+KEY_KP3 3
+KEY_KP4 4
+KEY_KP5 5
+KEY_KP6 6
+KEY_KP7 7
+KEY_KP8 8
+KEY_KP9 9
+KEY_KPDOT .
+KEY_KPSLASH /
+KEY_KPASTERISK *
+KEY_KPMINUS -
+KEY_KPPLUS +
+`
+
+func (l *KeyboardLayout) parse(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+
+	for scanner.Scan() {
+		line++
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		code, ok := KEYFromString[fields[0]]
+		if !ok {
+			return fmt.Errorf("line %d: unknown key %q", line, fields[0])
+		}
+
+		if len(fields) < 2 || len(fields) > 5 {
+			return fmt.Errorf("line %d: expected 1 to 4 symbols", line)
+		}
+
+		var key layoutKey
+
+		for i, field := range fields[1:] {
+			sym, err := parseKeysym(field)
+			if err != nil {
+				return fmt.Errorf("line %d: %v", line, err)
+			}
+			key.levels[i] = sym
+		}
+
+		plain := key.levels[0]
+
+		if len(fields) == 2 && !plain.dead {
+			key.levels[levelShift] = keysym{r: unicode.ToUpper(plain.r)}
+		}
+
+		key.caps = !plain.dead && unicode.IsLower(plain.r) &&
+			key.levels[levelShift] == keysym{r: unicode.ToUpper(plain.r)}
+
+		l.keys[code] = key
+	}
+
+	return scanner.Err()
+}
+
+// ParseKeyboardLayout reads a keyboard layout in a simple text format.
+// Each line consists of a key name as in KEYFromString followed by up to four
+// symbols: the plain symbol and the symbols produced with Shift, AltGr and
+// Shift+AltGr. If only the plain symbol is given, Shift produces its upper
+// case form. A symbol is a single character, a code point such as U+0020,
+// a dead key such as dead_acute or "none". Lines starting with # are ignored.
+// Space, Tab, Enter, Backspace and the keypad are predefined but can be
+// overridden.
+func ParseKeyboardLayout(name string, r io.Reader) (*KeyboardLayout, error) {
+	l := &KeyboardLayout{
+		Name: name,
+		keys: make(map[EvCode]layoutKey),
+	}
+
+	if err := l.parse(strings.NewReader(commonLayout)); err != nil {
+		return nil, err
+	}
+
+	if err := l.parse(r); err != nil {
+		return nil, fmt.Errorf("cannot parse keyboard layout %q: %w", name, err)
+	}
+
+	l.buildReverse()
+
+	return l, nil
+}
+
+func mustParseKeyboardLayout(name string, texts ...string) *KeyboardLayout {
+	l, err := ParseKeyboardLayout(name, strings.NewReader(strings.Join(texts, "\n")))
+	if err != nil {
+		panic(err)
+	}
+
+	return l
+}
+
+// symbol returns the symbol the key produces with the given modifiers.
+func (l *KeyboardLayout) symbol(code EvCode, shift, altGr, capsLock bool) (keysym, bool) {
+	key, ok := l.keys[code]
+	if !ok {
+		return keysym{}, false
+	}
+
+	if capsLock && key.caps && !altGr {
+		shift = !shift
+	}
+
+	level := 0
+	if shift {
+		level |= levelShift
+	}
+	if altGr {
+		level |= levelAltGr
+	}
+
+	sym := key.levels[level]
+
+	return sym, sym.r != 0
+}
+
+func (l *KeyboardLayout) buildReverse() {
+	l.runes = make(map[rune]layoutStroke)
+	l.dead = make(map[rune]layoutStroke)
+
+	for code, key := range l.keys {
+		if numLockKeys[code] {
+			continue
+		}
+
+		for level, sym := range key.levels {
+			if sym.r == 0 {
+				continue
+			}
+
+			stroke := layoutStroke{
+				code:  code,
+				shift: level&levelShift != 0,
+				altGr: level&levelAltGr != 0,
+			}
+
+			m := l.runes
+			if sym.dead {
+				m = l.dead
+			}
+
+			// prefer fewer modifiers, then the lower key code
+			if other, ok := m[sym.r]; ok && other.level() < level ||
+				ok && other.level() == level && other.code < code {
+				continue
+			}
+
+			m[sym.r] = stroke
+		}
+	}
+}
+
+func (s layoutStroke) level() int {
+	level := 0
+	if s.shift {
+		level |= levelShift
+	}
+	if s.altGr {
+		level |= levelAltGr
+	}
+
+	return level
+}
+
+// strokes returns the key presses that produce the given rune, assuming
+// CapsLock is off. Characters without a key of their own are composed
+// using dead keys.
+func (l *KeyboardLayout) strokes(r rune) ([]layoutStroke, bool) {
+	if s, ok := l.runes[r]; ok {
+		return []layoutStroke{s}, true
+	}
+
+	// a dead key followed by space produces the accent itself
+	if s, ok := l.dead[r]; ok {
+		if space, ok := l.runes[' ']; ok {
+			return []layoutStroke{s, space}, true
+		}
+	}
+
+	for accent, pairs := range deadCompose {
+		dead, ok := l.dead[accent]
+		if !ok {
+			continue
+		}
+
+		runes := []rune(pairs)
+		for i := 0; i+1 < len(runes); i += 2 {
+			if runes[i+1] != r {
+				continue
+			}
+
+			if base, ok := l.runes[runes[i]]; ok {
+				return []layoutStroke{dead, base}, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+const usLayout = `
+KEY_GRAVE ` + "`" + ` ~
+KEY_1 1 !
+KEY_2 2 @
+KEY_3 3 #
+KEY_4 4 $
+KEY_5 5 %
+KEY_6 6 ^
+KEY_7 7 &
+KEY_8 8 *
+KEY_9 9 (
+KEY_0 0 )
+KEY_MINUS - _
+KEY_EQUAL = +
+KEY_Q q
+KEY_W w
+KEY_E e
+KEY_R r
+KEY_T t
+KEY_Y y
+KEY_U u
+KEY_I i
+KEY_O o
+KEY_P p
+KEY_LEFTBRACE [ {
+KEY_RIGHTBRACE ] }
+KEY_BACKSLASH \ |
+KEY_A a
+KEY_S s
+KEY_D d
+KEY_F f
+KEY_G g
+KEY_H h
+KEY_J j
+KEY_K k
+KEY_L l
+KEY_SEMICOLON ; :
+KEY_APOSTROPHE ' "
+KEY_Z z
+KEY_X x
+KEY_C c
+KEY_V v
+KEY_B b
+KEY_N n
+KEY_M m
+KEY_COMMA , <
+KEY_DOT . >
+KEY_SLASH / ?
+`
+
+const ukLayout = `
+KEY_GRAVE ` + "`" + ` ¬ ¦
+KEY_2 2 "
+KEY_3 3 £
+KEY_4 4 $ €
+KEY_APOSTROPHE ' @
+KEY_BACKSLASH # ~
+KEY_102ND \ |
+`
+
+const deLayout = `
+KEY_GRAVE dead_circumflex °
+KEY_1 1 ! ¹
+KEY_2 2 " ²
+KEY_3 3 § ³
+KEY_4 4 $ ¼
+KEY_5 5 % ½
+KEY_6 6 & ¬
+KEY_7 7 / {
+KEY_8 8 ( [
+KEY_9 9 ) ]
+KEY_0 0 = }
+KEY_MINUS ß ? \ ẞ
+KEY_EQUAL dead_acute dead_grave
+KEY_Q q Q @
+KEY_E e E €
+KEY_Y z
+KEY_LEFTBRACE ü
+KEY_RIGHTBRACE + * ~
+KEY_SEMICOLON ö
+KEY_APOSTROPHE ä
+KEY_BACKSLASH # '
+KEY_102ND < > |
+KEY_Z y
+KEY_M m M µ
+KEY_COMMA , ;
+KEY_DOT . :
+KEY_SLASH - _
+KEY_KPDOT ,
+`
+
+const frLayout = `
+KEY_GRAVE ² none
+KEY_1 & 1
+KEY_2 é 2 dead_tilde
+KEY_3 " 3 #
+KEY_4 ' 4 {
+KEY_5 ( 5 [
+KEY_6 - 6 |
+KEY_7 è 7 dead_grave
+KEY_8 _ 8 \
+KEY_9 ç 9 ^
+KEY_0 à 0 @
+KEY_MINUS ) ° ]
+KEY_EQUAL = + }
+KEY_Q a
+KEY_W z
+KEY_E e E €
+KEY_LEFTBRACE dead_circumflex dead_diaeresis
+KEY_RIGHTBRACE $ £ ¤
+KEY_A q
+KEY_SEMICOLON m
+KEY_APOSTROPHE ù %
+KEY_BACKSLASH * µ
+KEY_102ND < >
+KEY_Z w
+KEY_M , ?
+KEY_COMMA ; .
+KEY_DOT : /
+KEY_SLASH ! §
+`
+
+// Built-in keyboard layouts.
+var (
+	LayoutUS = mustParseKeyboardLayout("us", usLayout)
+	LayoutUK = mustParseKeyboardLayout("uk", usLayout, ukLayout)
+	LayoutDE = mustParseKeyboardLayout("de", usLayout, deLayout)
+	LayoutFR = mustParseKeyboardLayout("fr", usLayout, frLayout)
+)
@@ -0,0 +1,149 @@
+package evdev
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// Match describes criteria to select input devices by. All criteria that are
+// set must be fulfilled for a device to match; zero values match any device.
+type Match struct {
+	BusType uint16 // one of BUS_*
+	Vendor  uint16
+	Product uint16
+
+	Name       string         // name as reported by the kernel, '*' and '?' are wildcards
+	NameRegexp *regexp.Regexp // regular expression the name must match
+	Phys       string         // physical location, '*' and '?' are wildcards
+	Uniq       string         // unique identifier, '*' and '?' are wildcards
+
+	Codes      map[EvType][]EvCode // event codes the device must support
+	Properties []EvProp            // properties the device must have
+	Class      DeviceClass         // classes the device must belong to
+}
+
+// globMatch reports whether s matches the pattern, in which '*' matches any
+// sequence of characters, including '/', and '?' matches a single character.
+func globMatch(pattern, s string) bool {
+	p, r := []rune(pattern), []rune(s)
+
+	// position after the last '*' and the position in s it was matched up to
+	star, next := -1, 0
+
+	for i, j := 0, 0; j < len(r) || i < len(p); {
+		switch {
+		case i < len(p) && p[i] == '*':
+			star, next = i+1, j
+			i++
+		case i < len(p) && j < len(r) && (p[i] == '?' || p[i] == r[j]):
+			i++
+			j++
+		case star != -1 && next < len(r):
+			// let the last '*' match one more character
+			next++
+			i, j = star, next
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// Matches returns true if the given device fulfills all criteria of the Match.
+func (m Match) Matches(info *DeviceInfo) bool {
+	if m.BusType != 0 && m.BusType != info.InputID.BusType {
+		return false
+	}
+
+	if m.Vendor != 0 && m.Vendor != info.InputID.Vendor {
+		return false
+	}
+
+	if m.Product != 0 && m.Product != info.InputID.Product {
+		return false
+	}
+
+	if m.Name != "" && !globMatch(m.Name, info.Name) {
+		return false
+	}
+
+	if m.NameRegexp != nil && !m.NameRegexp.MatchString(info.Name) {
+		return false
+	}
+
+	if m.Phys != "" && !globMatch(m.Phys, info.Phys) {
+		return false
+	}
+
+	if m.Uniq != "" && !globMatch(m.Uniq, info.Uniq) {
+		return false
+	}
+
+	for t, codes := range m.Codes {
+		if _, ok := info.Capabilities[t]; !ok {
+			return false
+		}
+
+		for _, code := range codes {
+			if !info.HasCode(t, code) {
+				return false
+			}
+		}
+	}
+
+	for _, p := range m.Properties {
+		if !info.HasProperty(p) {
+			return false
+		}
+	}
+
+	if m.Class != 0 && !info.Classify().Has(m.Class) {
+		return false
+	}
+
+	return true
+}
+
+// FindDevices returns all input devices that fulfill the criteria of the given Match.
+// The devices are looked up in sysfs, without opening their device nodes.
+func FindDevices(m Match) ([]DeviceInfo, error) {
+	var list []DeviceInfo
+
+	infos, err := ListDeviceInfos()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range infos {
+		if m.Matches(&infos[i]) {
+			list = append(list, infos[i])
+		}
+	}
+
+	return list, nil
+}
+
+// OpenFirstWithFlags opens the first input device that fulfills the criteria of
+// the given Match. The input device is opened with the specified flags (O_RDONLY etc.).
+// Returns an error if no device matches, or the device node could not be opened.
+func OpenFirstWithFlags(m Match, flags int) (*InputDevice, error) {
+	devices, err := FindDevices(m)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(devices) == 0 {
+		return nil, fmt.Errorf("could not find matching input device")
+	}
+
+	return OpenWithFlags(devices[0].Path, flags)
+}
+
+// OpenFirst opens the first input device that fulfills the criteria of the
+// given Match. The input device is opened with flag O_RDWR.
+// Returns an error if no device matches, or the device node could not be opened.
+func OpenFirst(m Match) (*InputDevice, error) {
+	return OpenFirstWithFlags(m, os.O_RDWR)
+}
@@ -0,0 +1,108 @@
+package evdev
+
+import (
+	"regexp"
+	"testing"
+)
+
+func Test_globMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{pattern: "", s: "", want: true},
+		{pattern: "", s: "a", want: false},
+		{pattern: "*", s: "", want: true},
+		{pattern: "*", s: "anything", want: true},
+		{pattern: "Logitech*", s: "Logitech USB Receiver", want: true},
+		{pattern: "Logitech*", s: "USB Logitech Receiver", want: false},
+		{pattern: "*Keyboard", s: "AT Translated Set 2 keyboard", want: false},
+		{pattern: "*Receiver*", s: "Logitech USB Receiver Mouse", want: true},
+		{pattern: "usb-*/input?", s: "usb-0000:00:14.0-2/input1", want: true},
+		{pattern: "usb-*/input?", s: "usb-0000:00:14.0-2/input10", want: false},
+		{pattern: "a*b*c", s: "aXbYbZc", want: true},
+		{pattern: "a*b*c", s: "aXbYcZ", want: false},
+		{pattern: "??", s: "äö", want: true},
+		{pattern: "[a-z]", s: "b", want: false},
+		{pattern: "[a-z]", s: "[a-z]", want: true},
+		{pattern: "x.y", s: "xzy", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := globMatch(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestMatchMatches(t *testing.T) {
+	keyboard := &DeviceInfo{
+		Name:    "Logitech USB Keyboard",
+		Phys:    "usb-0000:00:14.0-2/input0",
+		Uniq:    "",
+		InputID: InputID{BusType: BUS_USB, Vendor: 0x046d, Product: 0xc31c},
+		Capabilities: map[EvType][]EvCode{
+			EV_SYN: nil,
+			EV_KEY: {KEY_ESC, KEY_1, KEY_2, KEY_Q, KEY_W, KEY_A, KEY_F13},
+			EV_LED: {LED_NUML, LED_CAPSL},
+		},
+	}
+
+	touchpad := &DeviceInfo{
+		Name:    "SYNA8004:00 06CB:CD8B Touchpad",
+		Uniq:    "abc123",
+		InputID: InputID{BusType: BUS_I2C, Vendor: 0x06cb, Product: 0xcd8b},
+		Capabilities: map[EvType][]EvCode{
+			EV_KEY: {BTN_LEFT, BTN_TOOL_FINGER, BTN_TOUCH},
+			EV_ABS: {ABS_X, ABS_Y, ABS_MT_SLOT, ABS_MT_POSITION_X, ABS_MT_POSITION_Y},
+		},
+		Properties: []EvProp{INPUT_PROP_POINTER, INPUT_PROP_BUTTONPAD},
+	}
+
+	tests := []struct {
+		name  string
+		match Match
+		info  *DeviceInfo
+		want  bool
+	}{
+		{name: "empty match", match: Match{}, info: keyboard, want: true},
+		{name: "bus", match: Match{BusType: BUS_USB}, info: keyboard, want: true},
+		{name: "other bus", match: Match{BusType: BUS_USB}, info: touchpad, want: false},
+		{name: "vendor and product", match: Match{Vendor: 0x046d, Product: 0xc31c}, info: keyboard, want: true},
+		{name: "other vendor", match: Match{Vendor: 0x046d}, info: touchpad, want: false},
+		{name: "other product", match: Match{Vendor: 0x046d, Product: 0xc52b}, info: keyboard, want: false},
+		{name: "exact name", match: Match{Name: "Logitech USB Keyboard"}, info: keyboard, want: true},
+		{name: "name glob", match: Match{Name: "*Touchpad"}, info: touchpad, want: true},
+		{name: "name glob mismatch", match: Match{Name: "*Touchpad"}, info: keyboard, want: false},
+		{name: "name regexp", match: Match{NameRegexp: regexp.MustCompile(`(?i)keyboard$`)}, info: keyboard, want: true},
+		{name: "name regexp mismatch", match: Match{NameRegexp: regexp.MustCompile(`^SYNA`)}, info: keyboard, want: false},
+		{name: "phys glob", match: Match{Phys: "usb-*/input0"}, info: keyboard, want: true},
+		{name: "uniq", match: Match{Uniq: "abc*"}, info: touchpad, want: true},
+		{name: "empty uniq", match: Match{Uniq: "?*"}, info: keyboard, want: false},
+		{name: "codes", match: Match{Codes: map[EvType][]EvCode{EV_KEY: {KEY_F13}}}, info: keyboard, want: true},
+		{name: "missing code", match: Match{Codes: map[EvType][]EvCode{EV_KEY: {KEY_F14}}}, info: keyboard, want: false},
+		{name: "type without codes", match: Match{Codes: map[EvType][]EvCode{EV_LED: nil}}, info: touchpad, want: false},
+		{name: "properties", match: Match{Properties: []EvProp{INPUT_PROP_BUTTONPAD}}, info: touchpad, want: true},
+		{name: "missing property", match: Match{Properties: []EvProp{INPUT_PROP_DIRECT}}, info: touchpad, want: false},
+		{
+			name: "all criteria",
+			match: Match{
+				BusType: BUS_USB,
+				Vendor:  0x046d,
+				Name:    "Logitech*",
+				Codes:   map[EvType][]EvCode{EV_KEY: {KEY_F13}},
+			},
+			info: keyboard,
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.match.Matches(tt.info); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
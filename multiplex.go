@@ -0,0 +1,262 @@
+package evdev
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"syscall"
+)
+
+// DeviceEvent is an InputEvent tagged with the device it was read from.
+// If Err is set, reading from the device failed (e.g. with ENODEV because it
+// was unplugged), and the device has been removed from the Multiplexer and closed.
+type DeviceEvent struct {
+	Device *InputDevice
+	Event  InputEvent
+	Err    error
+}
+
+// Multiplexer reads events from a set of InputDevices in a single goroutine
+// and delivers them on one channel.
+//
+// Devices added to a Multiplexer are owned by it until they are removed again.
+// They must not be read from or closed by the caller in the meantime.
+type Multiplexer struct {
+	poller  *epoller
+	events  chan DeviceEvent
+	stop    chan struct{}
+	stopped chan struct{}
+
+	mu      sync.Mutex
+	devices map[int]*InputDevice
+	closed  bool
+}
+
+var errMultiplexerClosed = errors.New("multiplexer is closed")
+
+// NewMultiplexer creates a new Multiplexer without any devices.
+func NewMultiplexer() (*Multiplexer, error) {
+	p, err := newEpoller()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Multiplexer{
+		poller:  p,
+		events:  make(chan DeviceEvent, 64),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+		devices: make(map[int]*InputDevice),
+	}
+
+	go m.loop()
+
+	return m, nil
+}
+
+// Events returns the channel on which events of all devices are delivered.
+// The channel is closed when the Multiplexer is closed.
+func (m *Multiplexer) Events() <-chan DeviceEvent {
+	return m.events
+}
+
+// ReadContext returns the next event from the Events channel. It blocks until
+// an event is available, the Multiplexer is closed or the context is done, in
+// which case the context's error is returned.
+func (m *Multiplexer) ReadContext(ctx context.Context) (DeviceEvent, error) {
+	select {
+	case event, ok := <-m.events:
+		if !ok {
+			return DeviceEvent{}, errMultiplexerClosed
+		}
+		return event, nil
+	case <-ctx.Done():
+		return DeviceEvent{}, ctx.Err()
+	}
+}
+
+func deviceFd(d *InputDevice) (int, error) {
+	conn, err := d.file.SyscallConn()
+	if err != nil {
+		return -1, err
+	}
+
+	fd := -1
+	err = conn.Control(func(f uintptr) {
+		fd = int(f)
+	})
+
+	return fd, err
+}
+
+// Add adds a device to the Multiplexer.
+func (m *Multiplexer) Add(d *InputDevice) error {
+	fd, err := deviceFd(d)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return errMultiplexerClosed
+	}
+
+	if _, ok := m.devices[fd]; ok {
+		return errors.New("device already added")
+	}
+
+	if err := m.poller.add(fd); err != nil {
+		return err
+	}
+
+	m.devices[fd] = d
+
+	return nil
+}
+
+// Remove removes a device from the Multiplexer and hands it back to the caller.
+// The device is not closed.
+func (m *Multiplexer) Remove(d *InputDevice) error {
+	fd, err := deviceFd(d)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.devices[fd] != d {
+		return errors.New("device not found")
+	}
+
+	delete(m.devices, fd)
+
+	return m.poller.remove(fd)
+}
+
+// Devices returns the devices currently owned by the Multiplexer.
+func (m *Multiplexer) Devices() []*InputDevice {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	devices := make([]*InputDevice, 0, len(m.devices))
+	for _, d := range m.devices {
+		devices = append(devices, d)
+	}
+
+	return devices
+}
+
+// Close stops the Multiplexer, closes all devices it owns and closes the
+// events channel.
+func (m *Multiplexer) Close() error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	m.mu.Unlock()
+
+	close(m.stop)
+	_ = m.poller.wake()
+	<-m.stopped
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var err error
+	for fd, d := range m.devices {
+		if e := d.Close(); e != nil && err == nil {
+			err = e
+		}
+		delete(m.devices, fd)
+	}
+
+	m.poller.close()
+	close(m.events)
+
+	return err
+}
+
+func (m *Multiplexer) send(event DeviceEvent) bool {
+	select {
+	case m.events <- event:
+		return true
+	case <-m.stop:
+		return false
+	}
+}
+
+func (m *Multiplexer) loop() {
+	defer close(m.stopped)
+
+	buffer := make([]byte, eventsize*frameReadSize)
+
+	for {
+		fds, err := m.poller.wait(-1)
+
+		select {
+		case <-m.stop:
+			return
+		default:
+		}
+
+		if err != nil {
+			return
+		}
+
+		for _, fd := range fds {
+			// The lock is held while reading so a concurrent Remove cannot
+			// hand the device back, and its fd be closed and reused, before
+			// the read is done. The read does not block as fd is readable
+			// and in non-blocking mode.
+			m.mu.Lock()
+
+			d := m.devices[fd]
+			if d == nil {
+				m.mu.Unlock()
+				continue
+			}
+
+			n, err := syscall.Read(fd, buffer)
+			if err == syscall.EAGAIN || err == syscall.EINTR {
+				m.mu.Unlock()
+				continue
+			}
+			if err == nil && n == 0 {
+				err = io.EOF
+			}
+
+			var events []InputEvent
+			if err == nil {
+				events, err = decodeEvents(buffer[:n])
+			}
+
+			if err != nil {
+				delete(m.devices, fd)
+				_ = m.poller.remove(fd)
+				_ = d.Close()
+			}
+
+			m.mu.Unlock()
+
+			if err != nil {
+				if !m.send(DeviceEvent{Device: d, Err: err}) {
+					return
+				}
+
+				continue
+			}
+
+			for _, e := range events {
+				if !m.send(DeviceEvent{Device: d, Event: e}) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package evdev
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMultiplexer(t *testing.T) {
+	keyboard, keyboardW := pipeInputDevice(t)
+	mouse, mouseW := pipeInputDevice(t)
+
+	m, err := NewMultiplexer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	for _, d := range []*InputDevice{keyboard, mouse} {
+		if err := m.Add(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	key := InputEvent{Type: EV_KEY, Code: KEY_A, Value: 1}
+	rel := InputEvent{Type: EV_REL, Code: REL_X, Value: 3}
+	syn := InputEvent{Type: EV_SYN, Code: SYN_REPORT}
+
+	writeEvents(t, keyboardW, key, syn)
+	writeEvents(t, mouseW, rel, syn)
+
+	got := make(map[*InputDevice][]InputEvent)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	for i := 0; i < 4; i++ {
+		event, err := m.ReadContext(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event.Err != nil {
+			t.Fatal(event.Err)
+		}
+
+		got[event.Device] = append(got[event.Device], event.Event)
+	}
+
+	want := map[*InputDevice][]InputEvent{
+		keyboard: {key, syn},
+		mouse:    {rel, syn},
+	}
+
+	for d, events := range want {
+		if len(got[d]) != len(events) || got[d][0] != events[0] || got[d][1] != events[1] {
+			t.Errorf("events of %s = %v, want %v", d.Path(), got[d], events)
+		}
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := m.ReadContext(cancelled); !errors.Is(err, context.Canceled) {
+		t.Errorf("ReadContext() with cancelled context = %v, want %v", err, context.Canceled)
+	}
+
+	if err := m.Close(); err != nil {
+		t.Errorf("Close() = %v", err)
+	}
+
+	if _, err := m.ReadContext(context.Background()); err == nil {
+		t.Error("ReadContext() on closed multiplexer succeeded")
+	}
+}
+
+func TestMultiplexerRemovesClosedDevice(t *testing.T) {
+	d, w := pipeInputDevice(t)
+
+	m, err := NewMultiplexer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	if err := m.Add(d); err != nil {
+		t.Fatal(err)
+	}
+
+	// a closed write end reads as EOF, like an unplugged device
+	w.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	event, err := m.ReadContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if event.Device != d || event.Err == nil {
+		t.Errorf("got %+v, want error for device", event)
+	}
+
+	if devices := m.Devices(); len(devices) != 0 {
+		t.Errorf("Devices() = %v, want none", devices)
+	}
+}
+
+func TestMultiplexerRemove(t *testing.T) {
+	d, w := pipeInputDevice(t)
+
+	m, err := NewMultiplexer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	if err := m.Add(d); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Remove(d); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Remove(d); err == nil {
+		t.Error("second Remove() succeeded")
+	}
+
+	// events of a removed device are left to the caller
+	want := InputEvent{Type: EV_KEY, Code: KEY_A, Value: 1}
+	writeEvents(t, w, want)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if event, err := m.ReadContext(ctx); err == nil {
+		t.Errorf("got %+v from removed device", event)
+	}
+
+	got, err := d.ReadOne()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *got != want {
+		t.Errorf("ReadOne() = %v, want %v", got, want)
+	}
+}
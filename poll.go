@@ -0,0 +1,134 @@
+package evdev
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+)
+
+// epoller waits for a set of file descriptors to become readable.
+// A waiting goroutine can be woken up from another goroutine through an eventfd.
+type epoller struct {
+	epfd    int
+	wakefd  int
+	events  []syscall.EpollEvent
+	closing int32 // set by shutdown
+	users   int   // number of goroutines using the poller, if tracked
+}
+
+func newEpoller() (*epoller, error) {
+	epfd, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create epoll instance: %v", err)
+	}
+
+	r, _, errno := syscall.Syscall(syscall.SYS_EVENTFD2, 0, syscall.O_CLOEXEC|syscall.O_NONBLOCK, 0)
+	if errno != 0 {
+		_ = syscall.Close(epfd)
+		return nil, fmt.Errorf("cannot create eventfd: %v", errno)
+	}
+
+	p := &epoller{
+		epfd:   epfd,
+		wakefd: int(r),
+		events: make([]syscall.EpollEvent, 16),
+	}
+
+	if err := p.add(p.wakefd); err != nil {
+		p.close()
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *epoller) add(fd int) error {
+	event := syscall.EpollEvent{
+		Events: syscall.EPOLLIN,
+		Fd:     int32(fd),
+	}
+
+	if err := syscall.EpollCtl(p.epfd, syscall.EPOLL_CTL_ADD, fd, &event); err != nil {
+		return fmt.Errorf("cannot add fd to epoll instance: %v", err)
+	}
+
+	return nil
+}
+
+func (p *epoller) remove(fd int) error {
+	if err := syscall.EpollCtl(p.epfd, syscall.EPOLL_CTL_DEL, fd, nil); err != nil {
+		return fmt.Errorf("cannot remove fd from epoll instance: %v", err)
+	}
+
+	return nil
+}
+
+// wake interrupts a concurrent or the next call to wait.
+func (p *epoller) wake() error {
+	one := uint64(1)
+	buf := (*[8]byte)(unsafe.Pointer(&one))
+
+	_, err := syscall.Write(p.wakefd, buf[:])
+	if err == syscall.EAGAIN {
+		// the counter is saturated, so a wakeup is pending anyway
+		return nil
+	}
+
+	return err
+}
+
+// shutdown makes concurrent and later calls to wait return os.ErrClosed.
+func (p *epoller) shutdown() {
+	atomic.StoreInt32(&p.closing, 1)
+	_ = p.wake()
+}
+
+// wait blocks until at least one of the registered file descriptors is readable,
+// wake is called or the timeout in milliseconds expires. A negative timeout
+// means no timeout. It returns the readable file descriptors, which is empty
+// if the poller was woken up or timed out.
+func (p *epoller) wait(timeout int) ([]int, error) {
+	var n int
+	var err error
+
+	for {
+		if atomic.LoadInt32(&p.closing) != 0 {
+			return nil, os.ErrClosed
+		}
+
+		n, err = syscall.EpollWait(p.epfd, p.events, timeout)
+		if err != syscall.EINTR {
+			break
+		}
+	}
+
+	if atomic.LoadInt32(&p.closing) != 0 {
+		return nil, os.ErrClosed
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot wait for events: %v", err)
+	}
+
+	var fds []int
+
+	for _, event := range p.events[:n] {
+		fd := int(event.Fd)
+		if fd == p.wakefd {
+			var buf [8]byte
+			_, _ = syscall.Read(p.wakefd, buf[:])
+			continue
+		}
+
+		fds = append(fds, fd)
+	}
+
+	return fds, nil
+}
+
+func (p *epoller) close() {
+	_ = syscall.Close(p.wakefd)
+	_ = syscall.Close(p.epfd)
+}
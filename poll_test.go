@@ -0,0 +1,106 @@
+package evdev
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+// pipeInputDevice returns an InputDevice reading from a pipe instead of an
+// event node, and the write end of the pipe.
+func pipeInputDevice(t *testing.T) (*InputDevice, *os.File) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		r.Close()
+		w.Close()
+	})
+
+	return &InputDevice{file: r}, w
+}
+
+// writeEvents writes the events to w the way the kernel does.
+func writeEvents(t *testing.T, w *os.File, events ...InputEvent) {
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, binary.LittleEndian, events); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadContext(t *testing.T) {
+	d, w := pipeInputDevice(t)
+
+	want := InputEvent{Type: EV_KEY, Code: KEY_A, Value: 1}
+	writeEvents(t, w, want)
+
+	got, err := d.ReadContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestReadContextCancel(t *testing.T) {
+	d, _ := pipeInputDevice(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := d.ReadContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestReadContextClose(t *testing.T) {
+	d, _ := pipeInputDevice(t)
+
+	// set up the poller before reading concurrently
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _ = d.ReadContext(ctx)
+
+	done := make(chan error)
+	go func() {
+		_, err := d.readSliceContext(context.Background(), 1)
+		done <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+
+	closed := make(chan error)
+	go func() {
+		closed <- d.Close()
+	}()
+
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Errorf("Close() = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close blocked by a concurrent ReadContext")
+	}
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("ReadContext on closed device succeeded")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ReadContext not interrupted by Close")
+	}
+}
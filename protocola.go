@@ -0,0 +1,220 @@
+package evdev
+
+import (
+	"sort"
+	"syscall"
+)
+
+// ProtocolAConverter converts the event stream of a multitouch device that
+// implements the ABS_MT protocol A (anonymous contacts separated by
+// SYN_MT_REPORT) into protocol B (slots and tracking IDs).
+//
+// Contacts are matched to the contacts of the previous frame by their
+// position, so that a moving finger keeps its slot and tracking ID.
+// Events that are not related to multitouch are passed through unchanged.
+type ProtocolAConverter struct {
+	// MaxDistance is the maximum distance a contact may travel between two
+	// frames and still be considered the same contact. 0 means unlimited.
+	MaxDistance int32
+
+	dev      *InputDevice
+	slots    []protocolASlot
+	contact  map[EvCode]int32
+	contacts []map[EvCode]int32
+	other    []InputEvent
+	queue    []InputEvent
+	slot     int32
+	nextID   int32
+}
+
+type protocolASlot struct {
+	id     int32
+	values map[EvCode]int32
+}
+
+// NewProtocolAConverter creates a new ProtocolAConverter that reads from the
+// given device and reports contacts in up to numSlots slots. The device may be
+// nil if events are only fed in using HandleEvent.
+func NewProtocolAConverter(d *InputDevice, numSlots int) *ProtocolAConverter {
+	c := &ProtocolAConverter{
+		dev:     d,
+		slots:   make([]protocolASlot, numSlots),
+		contact: make(map[EvCode]int32),
+		slot:    -1,
+	}
+
+	for i := range c.slots {
+		c.slots[i].id = -1
+	}
+
+	return c
+}
+
+// ReadOne reads events from the device until a converted event is available
+// and returns it.
+func (c *ProtocolAConverter) ReadOne() (*InputEvent, error) {
+	for len(c.queue) == 0 {
+		event, err := c.dev.ReadOne()
+		if err != nil {
+			return nil, err
+		}
+
+		c.queue = c.HandleEvent(event)
+	}
+
+	event := c.queue[0]
+	c.queue = c.queue[1:]
+
+	return &event, nil
+}
+
+// HandleEvent feeds one protocol A event into the converter. Events are
+// collected until a SYN_REPORT is seen, at which point the complete
+// protocol B frame, including the SYN_REPORT, is returned.
+func (c *ProtocolAConverter) HandleEvent(event *InputEvent) []InputEvent {
+	switch {
+	case event.Type == EV_SYN && event.Code == SYN_MT_REPORT:
+		if len(c.contact) > 0 {
+			c.contacts = append(c.contacts, c.contact)
+			c.contact = make(map[EvCode]int32)
+		}
+	case event.Type == EV_SYN && event.Code == SYN_REPORT:
+		if len(c.contact) > 0 {
+			c.contacts = append(c.contacts, c.contact)
+			c.contact = make(map[EvCode]int32)
+		}
+
+		events := c.convert(event.Time)
+		events = append(events, c.other...)
+		events = append(events, *event)
+
+		c.contacts = nil
+		c.other = nil
+
+		return events
+	case event.Type == EV_ABS && isMTCode(event.Code):
+		if event.Code != ABS_MT_TRACKING_ID {
+			c.contact[event.Code] = event.Value
+		}
+	default:
+		c.other = append(c.other, *event)
+	}
+
+	return nil
+}
+
+type protocolAMatch struct {
+	slot     int
+	contact  int
+	distance int64
+}
+
+func contactDistance(a, b map[EvCode]int32) int64 {
+	dx := int64(a[ABS_MT_POSITION_X] - b[ABS_MT_POSITION_X])
+	dy := int64(a[ABS_MT_POSITION_Y] - b[ABS_MT_POSITION_Y])
+
+	return dx*dx + dy*dy
+}
+
+// convert assigns the contacts of the current frame to slots and returns
+// the protocol B events describing the changes.
+func (c *ProtocolAConverter) convert(time syscall.Timeval) []InputEvent {
+	var matches []protocolAMatch
+
+	for i, s := range c.slots {
+		if s.id == -1 {
+			continue
+		}
+
+		for j, contact := range c.contacts {
+			d := contactDistance(s.values, contact)
+			if c.MaxDistance > 0 && d > int64(c.MaxDistance)*int64(c.MaxDistance) {
+				continue
+			}
+
+			matches = append(matches, protocolAMatch{slot: i, contact: j, distance: d})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].distance < matches[j].distance
+	})
+
+	assigned := make([]int, len(c.slots))
+	for i := range assigned {
+		assigned[i] = -1
+	}
+
+	used := make([]bool, len(c.contacts))
+
+	for _, m := range matches {
+		if assigned[m.slot] != -1 || used[m.contact] {
+			continue
+		}
+
+		assigned[m.slot] = m.contact
+		used[m.contact] = true
+	}
+
+	// Contacts without a match start in a free slot.
+	for j := range c.contacts {
+		if used[j] {
+			continue
+		}
+
+		for i, s := range c.slots {
+			if s.id == -1 && assigned[i] == -1 {
+				assigned[i] = j
+				used[j] = true
+				break
+			}
+		}
+	}
+
+	var events []InputEvent
+
+	emit := func(slot int, code EvCode, value int32) {
+		if c.slot != int32(slot) {
+			events = append(events, InputEvent{Time: time, Type: EV_ABS, Code: ABS_MT_SLOT, Value: int32(slot)})
+			c.slot = int32(slot)
+		}
+
+		events = append(events, InputEvent{Time: time, Type: EV_ABS, Code: code, Value: value})
+	}
+
+	for i := range c.slots {
+		s := &c.slots[i]
+
+		if assigned[i] == -1 {
+			if s.id != -1 {
+				emit(i, ABS_MT_TRACKING_ID, -1)
+				s.id = -1
+				s.values = nil
+			}
+			continue
+		}
+
+		contact := c.contacts[assigned[i]]
+
+		if s.id == -1 {
+			s.id = c.nextID
+			c.nextID = (c.nextID + 1) & 0xffff
+			s.values = make(map[EvCode]int32)
+			emit(i, ABS_MT_TRACKING_ID, s.id)
+		}
+
+		var codes []EvCode
+		for code := range contact {
+			codes = append(codes, code)
+		}
+
+		for _, code := range sortCodes(codes) {
+			if old, ok := s.values[code]; !ok || old != contact[code] {
+				emit(i, code, contact[code])
+				s.values[code] = contact[code]
+			}
+		}
+	}
+
+	return events
+}
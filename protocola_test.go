@@ -0,0 +1,89 @@
+package evdev
+
+import (
+	"reflect"
+	"testing"
+)
+
+func protocolAFrame(contacts ...[2]int32) []InputEvent {
+	var events []InputEvent
+
+	for _, c := range contacts {
+		events = append(events,
+			InputEvent{Type: EV_ABS, Code: ABS_MT_POSITION_X, Value: c[0]},
+			InputEvent{Type: EV_ABS, Code: ABS_MT_POSITION_Y, Value: c[1]},
+			InputEvent{Type: EV_SYN, Code: SYN_MT_REPORT},
+		)
+	}
+
+	return append(events, InputEvent{Type: EV_SYN, Code: SYN_REPORT})
+}
+
+func TestProtocolAConverter(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame []InputEvent
+		want  []InputEvent
+	}{
+		{
+			name:  "two contacts begin",
+			frame: protocolAFrame([2]int32{10, 10}, [2]int32{100, 100}),
+			want: []InputEvent{
+				{Type: EV_ABS, Code: ABS_MT_SLOT, Value: 0},
+				{Type: EV_ABS, Code: ABS_MT_TRACKING_ID, Value: 0},
+				{Type: EV_ABS, Code: ABS_MT_POSITION_X, Value: 10},
+				{Type: EV_ABS, Code: ABS_MT_POSITION_Y, Value: 10},
+				{Type: EV_ABS, Code: ABS_MT_SLOT, Value: 1},
+				{Type: EV_ABS, Code: ABS_MT_TRACKING_ID, Value: 1},
+				{Type: EV_ABS, Code: ABS_MT_POSITION_X, Value: 100},
+				{Type: EV_ABS, Code: ABS_MT_POSITION_Y, Value: 100},
+				{Type: EV_SYN, Code: SYN_REPORT},
+			},
+		},
+		{
+			name:  "contacts reported in swapped order keep their slots",
+			frame: protocolAFrame([2]int32{101, 100}, [2]int32{11, 10}),
+			want: []InputEvent{
+				{Type: EV_ABS, Code: ABS_MT_SLOT, Value: 0},
+				{Type: EV_ABS, Code: ABS_MT_POSITION_X, Value: 11},
+				{Type: EV_ABS, Code: ABS_MT_SLOT, Value: 1},
+				{Type: EV_ABS, Code: ABS_MT_POSITION_X, Value: 101},
+				{Type: EV_SYN, Code: SYN_REPORT},
+			},
+		},
+		{
+			name:  "first contact lifted",
+			frame: protocolAFrame([2]int32{102, 100}),
+			want: []InputEvent{
+				{Type: EV_ABS, Code: ABS_MT_SLOT, Value: 0},
+				{Type: EV_ABS, Code: ABS_MT_TRACKING_ID, Value: -1},
+				{Type: EV_ABS, Code: ABS_MT_SLOT, Value: 1},
+				{Type: EV_ABS, Code: ABS_MT_POSITION_X, Value: 102},
+				{Type: EV_SYN, Code: SYN_REPORT},
+			},
+		},
+		{
+			name:  "all contacts lifted",
+			frame: []InputEvent{{Type: EV_SYN, Code: SYN_MT_REPORT}, {Type: EV_SYN, Code: SYN_REPORT}},
+			want: []InputEvent{
+				{Type: EV_ABS, Code: ABS_MT_TRACKING_ID, Value: -1},
+				{Type: EV_SYN, Code: SYN_REPORT},
+			},
+		},
+	}
+
+	c := NewProtocolAConverter(nil, 4)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []InputEvent
+			for i := range tt.frame {
+				got = append(got, c.HandleEvent(&tt.frame[i])...)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("HandleEvent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
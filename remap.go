@@ -0,0 +1,419 @@
+package evdev
+
+import (
+	"context"
+	"fmt"
+)
+
+// RemapRule transforms input events of a Remapper.
+type RemapRule interface {
+	// Remap is called with an event read from the source device. If the rule
+	// applies to the event, it returns the events to emit instead, which may be
+	// none, and true. Otherwise, it returns false and the next rule is tried.
+	// Release and autorepeat events of a key are always passed to the rule that
+	// handled its press.
+	Remap(r *Remapper, event InputEvent) ([]InputEvent, bool)
+
+	// Keys returns the key codes the rule may emit, so the virtual
+	// device can announce them.
+	Keys() []EvCode
+}
+
+// RemapConfig describes the behaviour of a Remapper.
+type RemapConfig struct {
+	// Name is the name of the virtual device. If empty, the name of the source
+	// device with a " (remapped)" suffix is used.
+	Name string
+	// Rules is the base rule chain. Rules are tried in order.
+	Rules []RemapRule
+	// Layers are additional rule chains that can be activated by name, for
+	// instance with LayerWhileHeld or LayerToggle. The rules of active layers
+	// take precedence over the base rules.
+	Layers map[string][]RemapRule
+}
+
+// Remapper grabs a source input device, runs its events through a chain of
+// rules and emits the result on a virtual device created via uinput.
+// A Remapper is not safe for concurrent use; rules are called from the
+// goroutine that calls Run or HandleEvent.
+type Remapper struct {
+	src    *InputDevice
+	dst    *VirtualDevice
+	config RemapConfig
+
+	layers  []string
+	pressed map[EvCode]RemapRule
+	held    map[EvCode]bool
+	out     []InputEvent
+}
+
+// NewRemapper grabs the source device and creates a virtual device that clones
+// the source device and additionally announces all keys the rules may emit.
+func NewRemapper(src *InputDevice, config RemapConfig) (*Remapper, error) {
+	if config.Name == "" {
+		name, err := src.Name()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get source device name: %w", err)
+		}
+		config.Name = name + " (remapped)"
+	}
+
+	dst, err := CloneDeviceWithOptions(src, remapCloneOptions(config))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := src.Grab(); err != nil {
+		dst.Close()
+		return nil, fmt.Errorf("failed to grab source device: %w", err)
+	}
+
+	return &Remapper{
+		src:     src,
+		dst:     dst,
+		config:  config,
+		pressed: make(map[EvCode]RemapRule),
+		held:    make(map[EvCode]bool),
+	}, nil
+}
+
+// remapCloneOptions returns the options to clone the source device of a
+// Remapper with.
+func remapCloneOptions(config RemapConfig) CloneOptions {
+	var keys []EvCode
+	seen := make(map[EvCode]bool)
+
+	addKeys := func(rules []RemapRule) {
+		for _, rule := range rules {
+			for _, code := range rule.Keys() {
+				if !seen[code] {
+					seen[code] = true
+					keys = append(keys, code)
+				}
+			}
+		}
+	}
+
+	addKeys(config.Rules)
+	for _, rules := range config.Layers {
+		addKeys(rules)
+	}
+
+	return CloneOptions{
+		Name:            config.Name,
+		AddCapabilities: map[EvType][]EvCode{EV_KEY: keys},
+		RemoveCapabilities: map[EvType][]EvCode{
+			// Autorepeat events are forwarded from the source device,
+			// the kernel must not generate its own.
+			EV_REP: nil,
+			// Force-feedback requests are not forwarded to the source device.
+			EV_FF: nil,
+		},
+	}
+}
+
+// ActivateLayer activates the layer with the given name. The most recently
+// activated layer takes precedence.
+func (r *Remapper) ActivateLayer(name string) {
+	r.DeactivateLayer(name)
+	r.layers = append(r.layers, name)
+}
+
+// DeactivateLayer deactivates the layer with the given name.
+func (r *Remapper) DeactivateLayer(name string) {
+	for i, l := range r.layers {
+		if l == name {
+			r.layers = append(r.layers[:i], r.layers[i+1:]...)
+			return
+		}
+	}
+}
+
+// LayerActive returns true if the layer with the given name is active.
+func (r *Remapper) LayerActive(name string) bool {
+	for _, l := range r.layers {
+		if l == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Remapper) remap(event InputEvent) ([]InputEvent, RemapRule) {
+	for i := len(r.layers) - 1; i >= 0; i-- {
+		for _, rule := range r.config.Layers[r.layers[i]] {
+			if events, ok := rule.Remap(r, event); ok {
+				return events, rule
+			}
+		}
+	}
+
+	for _, rule := range r.config.Rules {
+		if events, ok := rule.Remap(r, event); ok {
+			return events, rule
+		}
+	}
+
+	return []InputEvent{event}, nil
+}
+
+// HandleEvent runs one event through the rule chain. The resulting events are
+// buffered and written to the virtual device when a SYN_REPORT is received.
+func (r *Remapper) HandleEvent(event InputEvent) error {
+	if frame := r.handle(event); len(frame) > 0 {
+		return r.dst.WriteEvents(frame)
+	}
+
+	return nil
+}
+
+// handle runs one event through the rule chain and returns the frame to
+// write once a SYN_REPORT completes it.
+func (r *Remapper) handle(event InputEvent) []InputEvent {
+	if event.Type == EV_SYN {
+		if event.Code != SYN_REPORT || len(r.out) == 0 {
+			return nil
+		}
+
+		r.out = append(r.out, event)
+		return r.flush()
+	}
+
+	var events []InputEvent
+
+	if event.Type == EV_KEY && event.Value != 1 {
+		rule, ok := r.pressed[event.Code]
+		switch {
+		case ok && rule != nil:
+			events, _ = rule.Remap(r, event)
+		case ok:
+			events = []InputEvent{event}
+		default:
+			events, _ = r.remap(event)
+		}
+
+		if event.Value == 0 {
+			delete(r.pressed, event.Code)
+		}
+	} else {
+		var rule RemapRule
+		events, rule = r.remap(event)
+
+		if event.Type == EV_KEY {
+			r.pressed[event.Code] = rule
+		}
+	}
+
+	for _, e := range events {
+		e.Time = event.Time
+		r.out = append(r.out, e)
+	}
+
+	return nil
+}
+
+// flush returns the buffered events and tracks the keys they leave held
+// down on the virtual device.
+func (r *Remapper) flush() []InputEvent {
+	out := r.out
+	r.out = nil
+
+	for i := range out {
+		if out[i].Type == EV_KEY {
+			if out[i].Value == 0 {
+				delete(r.held, out[i].Code)
+			} else {
+				r.held[out[i].Code] = true
+			}
+		}
+	}
+
+	return out
+}
+
+// releaseHeld discards buffered events and returns a frame releasing all keys
+// held down on the virtual device, or nil if there are none.
+func (r *Remapper) releaseHeld() []InputEvent {
+	var codes []EvCode
+	for code := range r.held {
+		codes = append(codes, code)
+	}
+
+	r.out = nil
+	for _, code := range sortCodes(codes) {
+		r.out = append(r.out, InputEvent{Type: EV_KEY, Code: code, Value: 0})
+	}
+
+	if len(r.out) == 0 {
+		return nil
+	}
+
+	r.out = append(r.out, InputEvent{Type: EV_SYN, Code: SYN_REPORT})
+
+	return r.flush()
+}
+
+// Run reads events from the source device and remaps them until the context
+// is done or an error occurs.
+func (r *Remapper) Run(ctx context.Context) error {
+	for {
+		event, err := r.src.ReadContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		if err := r.HandleEvent(*event); err != nil {
+			return err
+		}
+	}
+}
+
+// Close releases all keys still held on the virtual device, destroys it and
+// releases the grab on the source device. The source device is not closed.
+// Close must not be called while Run is active.
+func (r *Remapper) Close() error {
+	err := r.dst.WriteEvents(r.releaseHeld())
+
+	_ = r.dst.Close()
+
+	if e := r.src.Ungrab(); e != nil && err == nil {
+		err = e
+	}
+
+	return err
+}
+
+type keyToKeyRule struct {
+	from, to EvCode
+}
+
+// KeyToKey returns a rule that maps one key to another.
+func KeyToKey(from, to EvCode) RemapRule {
+	return &keyToKeyRule{from: from, to: to}
+}
+
+func (k *keyToKeyRule) Remap(r *Remapper, event InputEvent) ([]InputEvent, bool) {
+	if event.Type != EV_KEY || event.Code != k.from {
+		return nil, false
+	}
+
+	event.Code = k.to
+
+	return []InputEvent{event}, true
+}
+
+func (k *keyToKeyRule) Keys() []EvCode {
+	return []EvCode{k.to}
+}
+
+type keyToComboRule struct {
+	from  EvCode
+	combo []EvCode
+}
+
+// KeyToCombo returns a rule that maps one key to a combination of keys, which
+// are pressed in the given order and released in reverse order.
+// Autorepeat is applied to the last key of the combination only.
+func KeyToCombo(from EvCode, combo ...EvCode) RemapRule {
+	return &keyToComboRule{from: from, combo: combo}
+}
+
+func (k *keyToComboRule) Remap(r *Remapper, event InputEvent) ([]InputEvent, bool) {
+	if event.Type != EV_KEY || event.Code != k.from {
+		return nil, false
+	}
+
+	var events []InputEvent
+
+	switch event.Value {
+	case 0:
+		for i := len(k.combo) - 1; i >= 0; i-- {
+			events = append(events, InputEvent{Type: EV_KEY, Code: k.combo[i], Value: 0})
+		}
+	case 1:
+		for _, code := range k.combo {
+			events = append(events, InputEvent{Type: EV_KEY, Code: code, Value: 1})
+		}
+	default:
+		if len(k.combo) > 0 {
+			events = append(events, InputEvent{Type: EV_KEY, Code: k.combo[len(k.combo)-1], Value: event.Value})
+		}
+	}
+
+	return events, true
+}
+
+func (k *keyToComboRule) Keys() []EvCode {
+	return k.combo
+}
+
+type swallowRule struct {
+	codes map[EvCode]bool
+}
+
+// Swallow returns a rule that drops all events of the given keys.
+func Swallow(codes ...EvCode) RemapRule {
+	s := &swallowRule{codes: make(map[EvCode]bool)}
+
+	for _, code := range codes {
+		s.codes[code] = true
+	}
+
+	return s
+}
+
+func (s *swallowRule) Remap(r *Remapper, event InputEvent) ([]InputEvent, bool) {
+	if event.Type != EV_KEY || !s.codes[event.Code] {
+		return nil, false
+	}
+
+	return nil, true
+}
+
+func (s *swallowRule) Keys() []EvCode {
+	return nil
+}
+
+type layerRule struct {
+	key    EvCode
+	layer  string
+	toggle bool
+}
+
+// LayerWhileHeld returns a rule that activates the given layer while the key is
+// held down. The key itself is not emitted.
+func LayerWhileHeld(key EvCode, layer string) RemapRule {
+	return &layerRule{key: key, layer: layer}
+}
+
+// LayerToggle returns a rule that toggles the given layer each time the key is
+// pressed. The key itself is not emitted.
+func LayerToggle(key EvCode, layer string) RemapRule {
+	return &layerRule{key: key, layer: layer, toggle: true}
+}
+
+func (l *layerRule) Remap(r *Remapper, event InputEvent) ([]InputEvent, bool) {
+	if event.Type != EV_KEY || event.Code != l.key {
+		return nil, false
+	}
+
+	switch {
+	case l.toggle && event.Value == 1:
+		if r.LayerActive(l.layer) {
+			r.DeactivateLayer(l.layer)
+		} else {
+			r.ActivateLayer(l.layer)
+		}
+	case !l.toggle && event.Value == 1:
+		r.ActivateLayer(l.layer)
+	case !l.toggle && event.Value == 0:
+		r.DeactivateLayer(l.layer)
+	}
+
+	return nil, true
+}
+
+func (l *layerRule) Keys() []EvCode {
+	return nil
+}
@@ -0,0 +1,245 @@
+package evdev
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newTestRemapper(config RemapConfig) *Remapper {
+	return &Remapper{
+		config:  config,
+		pressed: make(map[EvCode]RemapRule),
+		held:    make(map[EvCode]bool),
+	}
+}
+
+// remapAll runs the events through the Remapper and returns all frames it
+// would have written.
+func remapAll(r *Remapper, events []InputEvent) []InputEvent {
+	var out []InputEvent
+
+	for _, event := range events {
+		out = append(out, r.handle(event)...)
+	}
+
+	return out
+}
+
+func TestRemapper(t *testing.T) {
+	key := func(code EvCode, value int32) InputEvent {
+		return InputEvent{Type: EV_KEY, Code: code, Value: value}
+	}
+	syn := InputEvent{Type: EV_SYN, Code: SYN_REPORT}
+
+	tests := []struct {
+		name   string
+		config RemapConfig
+		input  []InputEvent
+		want   []InputEvent
+		held   []EvCode
+	}{
+		{
+			name:   "key to key",
+			config: RemapConfig{Rules: []RemapRule{KeyToKey(KEY_CAPSLOCK, KEY_ESC)}},
+			input: []InputEvent{
+				key(KEY_CAPSLOCK, 1), syn,
+				key(KEY_CAPSLOCK, 2), syn,
+				key(KEY_CAPSLOCK, 0), syn,
+			},
+			want: []InputEvent{
+				key(KEY_ESC, 1), syn,
+				key(KEY_ESC, 2), syn,
+				key(KEY_ESC, 0), syn,
+			},
+		},
+		{
+			name:   "unmatched events pass through",
+			config: RemapConfig{Rules: []RemapRule{KeyToKey(KEY_CAPSLOCK, KEY_ESC)}},
+			input: []InputEvent{
+				relEvent(REL_X, 3), key(KEY_A, 1), syn,
+				key(KEY_A, 0), syn,
+			},
+			want: []InputEvent{
+				relEvent(REL_X, 3), key(KEY_A, 1), syn,
+				key(KEY_A, 0), syn,
+			},
+		},
+		{
+			name:   "combo",
+			config: RemapConfig{Rules: []RemapRule{KeyToCombo(KEY_F13, KEY_LEFTCTRL, KEY_LEFTSHIFT, KEY_T)}},
+			input: []InputEvent{
+				key(KEY_F13, 1), syn,
+				key(KEY_F13, 2), syn,
+				key(KEY_F13, 0), syn,
+			},
+			want: []InputEvent{
+				key(KEY_LEFTCTRL, 1), key(KEY_LEFTSHIFT, 1), key(KEY_T, 1), syn,
+				key(KEY_T, 2), syn,
+				key(KEY_T, 0), key(KEY_LEFTSHIFT, 0), key(KEY_LEFTCTRL, 0), syn,
+			},
+		},
+		{
+			name:   "swallowed frames are not written",
+			config: RemapConfig{Rules: []RemapRule{Swallow(KEY_INSERT)}},
+			input: []InputEvent{
+				key(KEY_INSERT, 1), syn,
+				key(KEY_INSERT, 0), syn,
+			},
+			want: nil,
+		},
+		{
+			name: "first matching rule wins",
+			config: RemapConfig{Rules: []RemapRule{
+				KeyToKey(KEY_A, KEY_B),
+				KeyToKey(KEY_A, KEY_C),
+			}},
+			input: []InputEvent{key(KEY_A, 1), syn},
+			want:  []InputEvent{key(KEY_B, 1), syn},
+			held:  []EvCode{KEY_B},
+		},
+		{
+			name: "layer while held",
+			config: RemapConfig{
+				Rules:  []RemapRule{LayerWhileHeld(KEY_CAPSLOCK, "nav")},
+				Layers: map[string][]RemapRule{"nav": {KeyToKey(KEY_H, KEY_LEFT)}},
+			},
+			input: []InputEvent{
+				key(KEY_CAPSLOCK, 1), syn,
+				key(KEY_H, 1), syn,
+				key(KEY_CAPSLOCK, 0), syn,
+				// released by the rule that handled the press
+				key(KEY_H, 0), syn,
+				key(KEY_H, 1), syn,
+			},
+			want: []InputEvent{
+				key(KEY_LEFT, 1), syn,
+				key(KEY_LEFT, 0), syn,
+				key(KEY_H, 1), syn,
+			},
+			held: []EvCode{KEY_H},
+		},
+		{
+			name: "layer toggle",
+			config: RemapConfig{
+				Rules:  []RemapRule{LayerToggle(KEY_SCROLLLOCK, "num")},
+				Layers: map[string][]RemapRule{"num": {KeyToKey(KEY_J, KEY_KP1)}},
+			},
+			input: []InputEvent{
+				key(KEY_SCROLLLOCK, 1), key(KEY_SCROLLLOCK, 0), syn,
+				key(KEY_J, 1), key(KEY_J, 0), syn,
+				key(KEY_SCROLLLOCK, 1), key(KEY_SCROLLLOCK, 0), syn,
+				key(KEY_J, 1), key(KEY_J, 0), syn,
+			},
+			want: []InputEvent{
+				key(KEY_KP1, 1), key(KEY_KP1, 0), syn,
+				key(KEY_J, 1), key(KEY_J, 0), syn,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRemapper(tt.config)
+
+			if got := remapAll(r, tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+
+			var held []EvCode
+			for code := range r.held {
+				held = append(held, code)
+			}
+
+			if !reflect.DeepEqual(sortCodes(held), tt.held) {
+				t.Errorf("held keys = %v, want %v", held, tt.held)
+			}
+		})
+	}
+}
+
+func TestRemapperReleaseHeld(t *testing.T) {
+	key := func(code EvCode, value int32) InputEvent {
+		return InputEvent{Type: EV_KEY, Code: code, Value: value}
+	}
+	syn := InputEvent{Type: EV_SYN, Code: SYN_REPORT}
+
+	r := newTestRemapper(RemapConfig{Rules: []RemapRule{
+		KeyToKey(KEY_A, KEY_B),
+		KeyToCombo(KEY_F13, KEY_LEFTCTRL, KEY_C),
+	}})
+
+	remapAll(r, []InputEvent{
+		key(KEY_A, 1), syn,
+		key(KEY_F13, 1), syn,
+		key(KEY_X, 1), syn,
+		key(KEY_X, 0), syn,
+		// not yet written, so not held on the virtual device
+		key(KEY_Z, 1),
+	})
+
+	want := []InputEvent{
+		key(KEY_LEFTCTRL, 0),
+		key(KEY_C, 0),
+		key(KEY_B, 0),
+		syn,
+	}
+
+	if got := r.releaseHeld(); !reflect.DeepEqual(got, want) {
+		t.Errorf("releaseHeld() = %v, want %v", got, want)
+	}
+
+	if got := r.releaseHeld(); got != nil {
+		t.Errorf("second releaseHeld() = %v, want nil", got)
+	}
+
+	if got := r.handle(syn); got != nil {
+		t.Errorf("frame after releaseHeld() = %v, want nil", got)
+	}
+}
+
+func TestRemapCloneOptions(t *testing.T) {
+	config := RemapConfig{
+		Name: "remapped",
+		Rules: []RemapRule{
+			KeyToKey(KEY_CAPSLOCK, KEY_ESC),
+			KeyToCombo(KEY_F13, KEY_LEFTCTRL, KEY_C),
+			Swallow(KEY_INSERT),
+		},
+		Layers: map[string][]RemapRule{"nav": {KeyToKey(KEY_H, KEY_LEFT)}},
+	}
+
+	options := remapCloneOptions(config)
+
+	if options.Name != "remapped" {
+		t.Errorf("Name = %q, want %q", options.Name, "remapped")
+	}
+
+	// the capabilities of a keyboard with autorepeat and an LED
+	capabilities := map[EvType][]EvCode{
+		EV_SYN: nil,
+		EV_KEY: {KEY_ESC, KEY_CAPSLOCK, KEY_F13},
+		EV_LED: {LED_CAPSL},
+		EV_REP: nil,
+	}
+
+	cloneCapabilities(capabilities, options)
+
+	if _, ok := capabilities[EV_REP]; ok {
+		t.Error("EV_REP not removed")
+	}
+
+	if want := []EvCode{LED_CAPSL}; !reflect.DeepEqual(capabilities[EV_LED], want) {
+		t.Errorf("EV_LED = %v, want %v", capabilities[EV_LED], want)
+	}
+
+	keys := make(map[EvCode]bool)
+	for _, code := range capabilities[EV_KEY] {
+		keys[code] = true
+	}
+
+	for _, code := range []EvCode{KEY_ESC, KEY_CAPSLOCK, KEY_F13, KEY_LEFTCTRL, KEY_C, KEY_LEFT} {
+		if !keys[code] {
+			t.Errorf("EV_KEY = %v, missing %d", capabilities[EV_KEY], code)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package evdev
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+const (
+	byIDPath   = "/dev/input/by-id"
+	byPathPath = "/dev/input/by-path"
+)
+
+// StableID identifies an input device across reboots and re-plugging, unlike
+// the number of its event node. It is derived from the bus, vendor, product
+// and version IDs, the physical location and the unique identifier of the
+// device and can be persisted in configuration files.
+type StableID string
+
+func newStableID(id InputID, phys, uniq string) StableID {
+	return StableID(fmt.Sprintf("%04x:%04x:%04x:%04x/%s/%s",
+		id.BusType, id.Vendor, id.Product, id.Version,
+		url.PathEscape(phys), url.PathEscape(uniq)))
+}
+
+// StableID returns the StableID of the device.
+func (d *InputDevice) StableID() (StableID, error) {
+	id, err := d.InputID()
+	if err != nil {
+		return "", err
+	}
+
+	// physical location and unique ID are not available for all devices
+	phys, _ := d.PhysicalLocation()
+	uniq, _ := d.UniqueID()
+
+	return newStableID(id, phys, uniq), nil
+}
+
+// StableID returns the StableID of the device.
+func (info *DeviceInfo) StableID() StableID {
+	return newStableID(info.InputID, info.Phys, info.Uniq)
+}
+
+// ResolveStableID looks up the input device with the given StableID and
+// returns the path of its current event node.
+func ResolveStableID(id StableID) (string, error) {
+	infos, err := ListDeviceInfos()
+	if err != nil {
+		return "", err
+	}
+
+	return findStableID(infos, id)
+}
+
+func findStableID(infos []DeviceInfo, id StableID) (string, error) {
+	for i := range infos {
+		if infos[i].StableID() == id {
+			return infos[i].Path, nil
+		}
+	}
+
+	return "", fmt.Errorf("could not find input device with stable ID %q", id)
+}
+
+// linksTo returns all symlinks in dir that resolve to target.
+func linksTo(dir, target string) ([]string, error) {
+	var links []string
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.Type()&os.ModeSymlink == 0 {
+			continue
+		}
+
+		link := filepath.Join(dir, entry.Name())
+
+		resolved, err := filepath.EvalSymlinks(link)
+		if err == nil && resolved == target {
+			links = append(links, link)
+		}
+	}
+
+	return links, nil
+}
+
+// StableLinksForPath returns the symlinks in /dev/input/by-id and
+// /dev/input/by-path that point to the given device node.
+// Both lists are empty if udev did not create any links for the device.
+func StableLinksForPath(path string) (byID []string, byPath []string, err error) {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if byID, err = linksTo(byIDPath, target); err != nil {
+		return nil, nil, err
+	}
+
+	if byPath, err = linksTo(byPathPath, target); err != nil {
+		return nil, nil, err
+	}
+
+	return byID, byPath, nil
+}
+
+// StableLinks returns the symlinks in /dev/input/by-id and /dev/input/by-path
+// that point to the device's node.
+func (d *InputDevice) StableLinks() (byID []string, byPath []string, err error) {
+	return StableLinksForPath(d.Path())
+}
+
+// ResolveStableLink returns the event node a symlink in /dev/input/by-id or
+// /dev/input/by-path currently points to.
+func ResolveStableLink(link string) (string, error) {
+	return filepath.EvalSymlinks(link)
+}
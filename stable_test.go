@@ -0,0 +1,164 @@
+package evdev
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_newStableID(t *testing.T) {
+	usb := InputID{BusType: BUS_USB, Vendor: 0x046d, Product: 0xc52b, Version: 0x0111}
+
+	tests := []struct {
+		name string
+		id   InputID
+		phys string
+		uniq string
+		want StableID
+	}{
+		{
+			name: "ids only",
+			id:   InputID{BusType: BUS_I8042, Vendor: 0x0001, Product: 0x0001, Version: 0xab41},
+			want: "0011:0001:0001:ab41//",
+		},
+		{
+			name: "phys is escaped",
+			id:   usb,
+			phys: "usb-0000:00:14.0-2/input0",
+			want: "0003:046d:c52b:0111/usb-0000:00:14.0-2%2Finput0/",
+		},
+		{
+			name: "uniq",
+			id:   usb,
+			phys: "usb-0000:00:14.0-2/input0",
+			uniq: "4c:87:5d:2a:11:09",
+			want: "0003:046d:c52b:0111/usb-0000:00:14.0-2%2Finput0/4c:87:5d:2a:11:09",
+		},
+		{
+			name: "spaces",
+			id:   usb,
+			uniq: "a b",
+			want: "0003:046d:c52b:0111//a%20b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newStableID(tt.id, tt.phys, tt.uniq); got != tt.want {
+				t.Errorf("newStableID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_findStableID(t *testing.T) {
+	mouse := InputID{BusType: BUS_USB, Vendor: 0x046d, Product: 0xc077, Version: 0x0111}
+
+	classPath := writeFakeSysfs(t, []fakeSysfsDevice{
+		{event: "event3", name: "AT Translated Set 2 keyboard", phys: "isa0060/serio0/input0",
+			id: InputID{BusType: BUS_I8042, Vendor: 0x0001, Product: 0x0001, Version: 0xab41}},
+		{event: "event7", name: "Logitech USB Optical Mouse", phys: "usb-0000:00:14.0-1/input0", id: mouse},
+		{event: "event9", name: "Logitech USB Optical Mouse", phys: "usb-0000:00:14.0-2/input0", id: mouse},
+		{event: "event12", name: "Headset", uniq: "4c:87:5d:2a:11:09",
+			id: InputID{BusType: BUS_BLUETOOTH, Vendor: 0x05ac, Product: 0x0220}},
+	})
+
+	infos, err := listDeviceInfos(classPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(infos) != 4 {
+		t.Fatalf("listDeviceInfos() returned %d devices, want 4", len(infos))
+	}
+
+	tests := []struct {
+		name    string
+		id      StableID
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "serio keyboard",
+			id:   "0011:0001:0001:ab41/isa0060%2Fserio0%2Finput0/",
+			want: "/dev/input/event3",
+		},
+		{
+			name: "same model in first port",
+			id:   "0003:046d:c077:0111/usb-0000:00:14.0-1%2Finput0/",
+			want: "/dev/input/event7",
+		},
+		{
+			name: "same model in second port",
+			id:   "0003:046d:c077:0111/usb-0000:00:14.0-2%2Finput0/",
+			want: "/dev/input/event9",
+		},
+		{
+			name: "bluetooth by uniq",
+			id:   "0005:05ac:0220:0000//4c:87:5d:2a:11:09",
+			want: "/dev/input/event12",
+		},
+		{
+			name:    "unplugged",
+			id:      "0003:046d:c077:0111/usb-0000:00:14.0-3%2Finput0/",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findStableID(infos, tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findStableID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("findStableID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_linksTo(t *testing.T) {
+	dir := t.TempDir()
+
+	target := filepath.Join(dir, "event7")
+	if err := os.WriteFile(target, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	other := filepath.Join(dir, "event9")
+	if err := os.WriteFile(other, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	byID := filepath.Join(dir, "by-id")
+	if err := os.Mkdir(byID, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	links := map[string]string{
+		"usb-Logitech_USB_Optical_Mouse-event-mouse": "../event7",
+		"usb-Logitech_USB_Keyboard-event-kbd":        "../event9",
+	}
+
+	for name, dest := range links {
+		if err := os.Symlink(dest, filepath.Join(byID, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := linksTo(byID, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := filepath.Join(byID, "usb-Logitech_USB_Optical_Mouse-event-mouse")
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("linksTo() = %v, want [%s]", got, want)
+	}
+
+	if got, err := linksTo(filepath.Join(dir, "by-path"), target); err != nil || got != nil {
+		t.Errorf("linksTo() of missing directory = %v, %v, want nil, nil", got, err)
+	}
+}
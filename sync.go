@@ -0,0 +1,330 @@
+package evdev
+
+import (
+	"fmt"
+	"sort"
+	"syscall"
+)
+
+// SyncReader reads events from an InputDevice and keeps track of the state of
+// its keys, LEDs, switches, absolute axes and multitouch slots.
+//
+// When the kernel's event buffer overflows, it reports a SYN_DROPPED event.
+// SyncReader hides this from the caller: it discards the incomplete frame,
+// re-reads the device state and emits synthetic events for everything that
+// changed in the meantime, so the caller never ends up with a stuck key or
+// a stale touch.
+type SyncReader struct {
+	dev *InputDevice
+	src stateSource
+
+	state   map[EvType]StateMap
+	abs     map[EvCode]int32
+	slots   []map[EvCode]int32
+	slot    int32
+	queue   []InputEvent
+	dropped bool
+}
+
+// stateSource is the part of InputDevice used to read the current state of
+// a device.
+type stateSource interface {
+	State(t EvType) (StateMap, error)
+	AbsInfos() (map[EvCode]AbsInfo, error)
+	MTSlotValues(code EvCode) ([]int32, error)
+}
+
+var syncStateTypes = []EvType{EV_KEY, EV_SW, EV_LED}
+
+// NewSyncReader creates a new SyncReader for the given device and reads its
+// initial state.
+func NewSyncReader(d *InputDevice) (*SyncReader, error) {
+	s, err := newSyncReader(d)
+	if err != nil {
+		return nil, err
+	}
+
+	s.dev = d
+
+	return s, nil
+}
+
+func newSyncReader(src stateSource) (*SyncReader, error) {
+	s := &SyncReader{
+		src:   src,
+		state: make(map[EvType]StateMap),
+		abs:   make(map[EvCode]int32),
+	}
+
+	for _, t := range syncStateTypes {
+		st, err := src.State(t)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get state: %v", err)
+		}
+		s.state[t] = st
+	}
+
+	absInfos, err := src.AbsInfos()
+	if err != nil {
+		return nil, err
+	}
+
+	for code, absInfo := range absInfos {
+		if isMTCode(code) {
+			continue
+		}
+		s.abs[code] = absInfo.Value
+	}
+
+	if slotInfo, ok := absInfos[ABS_MT_SLOT]; ok {
+		s.slot = slotInfo.Value
+
+		slots, err := s.readSlots(absInfos)
+		if err != nil {
+			return nil, err
+		}
+		s.slots = slots
+	}
+
+	return s, nil
+}
+
+// Device returns the InputDevice the SyncReader reads from.
+func (s *SyncReader) Device() *InputDevice {
+	return s.dev
+}
+
+// ReadOne reads one InputEvent from the device. It blocks until an event has
+// been received or an error has occurred. SYN_DROPPED events are never returned;
+// instead, the events needed to bring the caller up to date are returned,
+// followed by a SYN_REPORT.
+func (s *SyncReader) ReadOne() (*InputEvent, error) {
+	for {
+		if len(s.queue) > 0 {
+			event := s.queue[0]
+			s.queue = s.queue[1:]
+			s.update(&event)
+
+			return &event, nil
+		}
+
+		event, err := s.dev.ReadOne()
+		if err != nil {
+			return nil, err
+		}
+
+		if event.Type == EV_SYN && event.Code == SYN_DROPPED {
+			s.dropped = true
+			continue
+		}
+
+		if s.dropped {
+			// Discard everything up to and including the next SYN_REPORT,
+			// then synchronize with the current device state.
+			if event.Type == EV_SYN && event.Code == SYN_REPORT {
+				s.dropped = false
+
+				if s.queue, err = s.resync(event.Time); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		s.update(event)
+
+		return event, nil
+	}
+}
+
+// State returns the tracked state of the given type, which must be one of
+// EV_KEY, EV_SW or EV_LED.
+func (s *SyncReader) State(t EvType) StateMap {
+	st := StateMap{}
+	for code, value := range s.state[t] {
+		st[code] = value
+	}
+
+	return st
+}
+
+// AbsValue returns the tracked value of the given absolute axis.
+func (s *SyncReader) AbsValue(code EvCode) int32 {
+	return s.abs[code]
+}
+
+// SlotValue returns the tracked value of the given ABS_MT_* axis in the given
+// multitouch slot. The boolean is false if the slot or axis does not exist.
+func (s *SyncReader) SlotValue(slot int, code EvCode) (int32, bool) {
+	if slot < 0 || slot >= len(s.slots) {
+		return 0, false
+	}
+
+	value, ok := s.slots[slot][code]
+	return value, ok
+}
+
+func isMTCode(code EvCode) bool {
+	return code >= ABS_MT_TOUCH_MAJOR && code <= ABS_MT_TOOL_Y
+}
+
+func (s *SyncReader) update(event *InputEvent) {
+	switch event.Type {
+	case EV_KEY, EV_SW, EV_LED:
+		if st, ok := s.state[event.Type]; ok {
+			st[event.Code] = event.Value != 0
+		}
+	case EV_ABS:
+		switch {
+		case event.Code == ABS_MT_SLOT:
+			s.slot = event.Value
+			s.abs[event.Code] = event.Value
+		case isMTCode(event.Code):
+			if s.slot >= 0 && int(s.slot) < len(s.slots) {
+				s.slots[s.slot][event.Code] = event.Value
+			}
+		default:
+			s.abs[event.Code] = event.Value
+		}
+	}
+}
+
+func (s *SyncReader) readSlots(absInfos map[EvCode]AbsInfo) ([]map[EvCode]int32, error) {
+	slotInfo := absInfos[ABS_MT_SLOT]
+	slots := make([]map[EvCode]int32, slotInfo.Maximum+1)
+
+	for i := range slots {
+		slots[i] = make(map[EvCode]int32)
+	}
+
+	for code := range absInfos {
+		if !isMTCode(code) {
+			continue
+		}
+
+		values, err := s.src.MTSlotValues(code)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get slot values: %v", err)
+		}
+
+		for i, value := range values {
+			slots[i][code] = value
+		}
+	}
+
+	return slots, nil
+}
+
+func sortCodes(codes []EvCode) []EvCode {
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	return codes
+}
+
+// resync reads the current device state and returns the events needed to
+// bring the tracked state up to date.
+func (s *SyncReader) resync(time syscall.Timeval) ([]InputEvent, error) {
+	var events, terminated []InputEvent
+
+	emit := func(t EvType, code EvCode, value int32) {
+		events = append(events, InputEvent{Time: time, Type: t, Code: code, Value: value})
+	}
+
+	for _, t := range syncStateTypes {
+		st, err := s.src.State(t)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get state: %v", err)
+		}
+
+		var codes []EvCode
+		for code := range st {
+			codes = append(codes, code)
+		}
+
+		for _, code := range sortCodes(codes) {
+			if st[code] != s.state[t][code] {
+				value := int32(0)
+				if st[code] {
+					value = 1
+				}
+				emit(t, code, value)
+			}
+		}
+	}
+
+	absInfos, err := s.src.AbsInfos()
+	if err != nil {
+		return nil, err
+	}
+
+	var absCodes, mtCodes []EvCode
+	for code := range absInfos {
+		switch {
+		case isMTCode(code):
+			mtCodes = append(mtCodes, code)
+		case code != ABS_MT_SLOT:
+			absCodes = append(absCodes, code)
+		}
+	}
+
+	for _, code := range sortCodes(absCodes) {
+		if value := absInfos[code].Value; value != s.abs[code] {
+			emit(EV_ABS, code, value)
+		}
+	}
+
+	if len(s.slots) > 0 {
+		slots, err := s.readSlots(absInfos)
+		if err != nil {
+			return nil, err
+		}
+
+		// Always select the slot explicitly, the terminating frame
+		// may have left a different one selected.
+		currentSlot := int32(-1)
+		sortCodes(mtCodes)
+
+		for i, slot := range slots {
+			if i >= len(s.slots) {
+				break
+			}
+
+			old := s.slots[i]
+
+			for _, code := range mtCodes {
+				if slot[code] == old[code] {
+					continue
+				}
+
+				if currentSlot != int32(i) {
+					emit(EV_ABS, ABS_MT_SLOT, int32(i))
+					currentSlot = int32(i)
+				}
+
+				// A contact that was replaced by a new one must be ended
+				// in a frame of its own before the new one can start.
+				if code == ABS_MT_TRACKING_ID && old[code] != -1 && slot[code] != -1 {
+					terminated = append(terminated,
+						InputEvent{Time: time, Type: EV_ABS, Code: ABS_MT_SLOT, Value: int32(i)},
+						InputEvent{Time: time, Type: EV_ABS, Code: ABS_MT_TRACKING_ID, Value: -1})
+				}
+
+				emit(EV_ABS, code, slot[code])
+			}
+		}
+
+		value := absInfos[ABS_MT_SLOT].Value
+		if (currentSlot == -1 && value != s.slot) || (currentSlot != -1 && value != currentSlot) {
+			emit(EV_ABS, ABS_MT_SLOT, value)
+		}
+	}
+
+	if len(terminated) > 0 {
+		terminated = append(terminated, InputEvent{Time: time, Type: EV_SYN, Code: SYN_REPORT})
+	}
+
+	if len(events) > 0 {
+		emit(EV_SYN, SYN_REPORT, 0)
+	}
+
+	return append(terminated, events...), nil
+}
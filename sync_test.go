@@ -0,0 +1,284 @@
+package evdev
+
+import (
+	"reflect"
+	"syscall"
+	"testing"
+)
+
+// fakeStateSource is a stateSource backed by maps instead of a device. All
+// methods fail with err if it is set.
+type fakeStateSource struct {
+	state    map[EvType]StateMap
+	absInfos map[EvCode]AbsInfo
+	slots    map[EvCode][]int32
+	err      error
+}
+
+func (f *fakeStateSource) State(t EvType) (StateMap, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	st := StateMap{}
+	for code, value := range f.state[t] {
+		st[code] = value
+	}
+
+	return st, nil
+}
+
+func (f *fakeStateSource) AbsInfos() (map[EvCode]AbsInfo, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	absInfos := make(map[EvCode]AbsInfo)
+	for code, info := range f.absInfos {
+		absInfos[code] = info
+	}
+
+	return absInfos, nil
+}
+
+func (f *fakeStateSource) MTSlotValues(code EvCode) ([]int32, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return append([]int32{}, f.slots[code]...), nil
+}
+
+// setAbs sets the current value of an absolute axis.
+func (f *fakeStateSource) setAbs(code EvCode, value int32) {
+	info := f.absInfos[code]
+	info.Value = value
+	f.absInfos[code] = info
+}
+
+// newFakeTouchpad returns a fakeStateSource with two keys, an LED, an
+// absolute axis and two multitouch slots, the first one with a contact.
+func newFakeTouchpad() *fakeStateSource {
+	return &fakeStateSource{
+		state: map[EvType]StateMap{
+			EV_KEY: {KEY_A: false, KEY_B: true},
+			EV_SW:  {},
+			EV_LED: {LED_NUML: false},
+		},
+		absInfos: map[EvCode]AbsInfo{
+			ABS_X:              {Value: 10, Maximum: 100},
+			ABS_MT_SLOT:        {Value: 0, Maximum: 1},
+			ABS_MT_TRACKING_ID: {Minimum: -1, Maximum: 65535},
+			ABS_MT_POSITION_X:  {Maximum: 100},
+		},
+		slots: map[EvCode][]int32{
+			ABS_MT_TRACKING_ID: {5, -1},
+			ABS_MT_POSITION_X:  {40, 0},
+		},
+	}
+}
+
+func TestSyncReaderResync(t *testing.T) {
+	now := syscall.Timeval{Sec: 1, Usec: 2}
+
+	ev := func(t EvType, code EvCode, value int32) InputEvent {
+		return InputEvent{Time: now, Type: t, Code: code, Value: value}
+	}
+	syn := ev(EV_SYN, SYN_REPORT, 0)
+
+	tests := []struct {
+		name   string
+		change func(f *fakeStateSource)
+		want   []InputEvent
+	}{
+		{
+			name:   "unchanged",
+			change: func(f *fakeStateSource) {},
+			want:   nil,
+		},
+		{
+			name: "keys and leds",
+			change: func(f *fakeStateSource) {
+				f.state[EV_KEY][KEY_A] = true
+				f.state[EV_KEY][KEY_B] = false
+				f.state[EV_LED][LED_NUML] = true
+			},
+			want: []InputEvent{
+				ev(EV_KEY, KEY_A, 1),
+				ev(EV_KEY, KEY_B, 0),
+				ev(EV_LED, LED_NUML, 1),
+				syn,
+			},
+		},
+		{
+			name: "absolute axis",
+			change: func(f *fakeStateSource) {
+				f.setAbs(ABS_X, 20)
+			},
+			want: []InputEvent{
+				ev(EV_ABS, ABS_X, 20),
+				syn,
+			},
+		},
+		{
+			name: "contact lifted",
+			change: func(f *fakeStateSource) {
+				f.slots[ABS_MT_TRACKING_ID][0] = -1
+			},
+			want: []InputEvent{
+				ev(EV_ABS, ABS_MT_SLOT, 0),
+				ev(EV_ABS, ABS_MT_TRACKING_ID, -1),
+				syn,
+			},
+		},
+		{
+			name: "contact replaced",
+			change: func(f *fakeStateSource) {
+				f.slots[ABS_MT_TRACKING_ID][0] = 6
+				f.slots[ABS_MT_POSITION_X][0] = 50
+			},
+			want: []InputEvent{
+				ev(EV_ABS, ABS_MT_SLOT, 0),
+				ev(EV_ABS, ABS_MT_TRACKING_ID, -1),
+				syn,
+				ev(EV_ABS, ABS_MT_SLOT, 0),
+				ev(EV_ABS, ABS_MT_POSITION_X, 50),
+				ev(EV_ABS, ABS_MT_TRACKING_ID, 6),
+				syn,
+			},
+		},
+		{
+			name: "contact in other slot",
+			change: func(f *fakeStateSource) {
+				f.slots[ABS_MT_TRACKING_ID][1] = 7
+				f.slots[ABS_MT_POSITION_X][1] = 60
+			},
+			want: []InputEvent{
+				ev(EV_ABS, ABS_MT_SLOT, 1),
+				ev(EV_ABS, ABS_MT_POSITION_X, 60),
+				ev(EV_ABS, ABS_MT_TRACKING_ID, 7),
+				ev(EV_ABS, ABS_MT_SLOT, 0),
+				syn,
+			},
+		},
+		{
+			name: "slot selected",
+			change: func(f *fakeStateSource) {
+				f.setAbs(ABS_MT_SLOT, 1)
+			},
+			want: []InputEvent{
+				ev(EV_ABS, ABS_MT_SLOT, 1),
+				syn,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeTouchpad()
+
+			s, err := newSyncReader(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tt.change(f)
+
+			got, err := s.resync(now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resync() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncReaderUpdate(t *testing.T) {
+	s, err := newSyncReader(newFakeTouchpad())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, event := range []InputEvent{
+		{Type: EV_KEY, Code: KEY_A, Value: 1},
+		{Type: EV_ABS, Code: ABS_X, Value: 30},
+		{Type: EV_ABS, Code: ABS_MT_SLOT, Value: 1},
+		{Type: EV_ABS, Code: ABS_MT_TRACKING_ID, Value: 8},
+	} {
+		event := event
+		s.update(&event)
+	}
+
+	if st := s.State(EV_KEY); !st[KEY_A] || !st[KEY_B] {
+		t.Errorf("State(EV_KEY) = %v, want KEY_A and KEY_B pressed", st)
+	}
+
+	if value := s.AbsValue(ABS_X); value != 30 {
+		t.Errorf("AbsValue(ABS_X) = %d, want 30", value)
+	}
+
+	if value, ok := s.SlotValue(1, ABS_MT_TRACKING_ID); !ok || value != 8 {
+		t.Errorf("SlotValue(1, ABS_MT_TRACKING_ID) = %d, %v, want 8, true", value, ok)
+	}
+
+	if value, ok := s.SlotValue(0, ABS_MT_TRACKING_ID); !ok || value != 5 {
+		t.Errorf("SlotValue(0, ABS_MT_TRACKING_ID) = %d, %v, want 5, true", value, ok)
+	}
+}
+
+func TestSyncReaderReadOne(t *testing.T) {
+	src := newFakeTouchpad()
+
+	s, err := newSyncReader(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, w := pipeInputDevice(t)
+	s.dev = d
+
+	ev := func(sec int64, t EvType, code EvCode, value int32) InputEvent {
+		return InputEvent{Time: syscall.NsecToTimeval(sec * 1e9), Type: t, Code: code, Value: value}
+	}
+
+	writeEvents(t, w,
+		ev(1, EV_ABS, ABS_X, 15), ev(1, EV_SYN, SYN_REPORT, 0),
+		ev(2, EV_SYN, SYN_DROPPED, 0),
+		// the rest of the incomplete frame is discarded
+		ev(2, EV_KEY, KEY_C, 1), ev(2, EV_ABS, ABS_X, 20),
+		ev(3, EV_SYN, SYN_REPORT, 0),
+		ev(4, EV_KEY, KEY_A, 0), ev(4, EV_SYN, SYN_REPORT, 0),
+	)
+
+	// changes lost in the dropped events
+	src.state[EV_KEY][KEY_A] = true
+	src.setAbs(ABS_X, 30)
+
+	want := []InputEvent{
+		ev(1, EV_ABS, ABS_X, 15), ev(1, EV_SYN, SYN_REPORT, 0),
+		ev(3, EV_KEY, KEY_A, 1), ev(3, EV_ABS, ABS_X, 30), ev(3, EV_SYN, SYN_REPORT, 0),
+		ev(4, EV_KEY, KEY_A, 0), ev(4, EV_SYN, SYN_REPORT, 0),
+	}
+
+	for i := range want {
+		got, err := s.ReadOne()
+		if err != nil {
+			t.Fatalf("event %d: %v", i, err)
+		}
+
+		if *got != want[i] {
+			t.Errorf("event %d = %v, want %v", i, got, want[i])
+		}
+	}
+
+	if st := s.State(EV_KEY); st[KEY_A] || st[KEY_C] {
+		t.Errorf("State(EV_KEY) = %v, want KEY_A and KEY_C released", st)
+	}
+
+	if value := s.AbsValue(ABS_X); value != 30 {
+		t.Errorf("AbsValue(ABS_X) = %d, want 30", value)
+	}
+}
@@ -0,0 +1,189 @@
+package evdev
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const sysfsInputPath = "/sys/class/input"
+
+// DeviceInfo describes an input device as exported by the kernel in sysfs.
+// It is gathered without opening the device node.
+type DeviceInfo struct {
+	Path         string // path of the event device node, e.g. /dev/input/event3
+	SysfsPath    string // path of the input device in sysfs, e.g. /sys/devices/.../input/input5
+	Name         string
+	Phys         string
+	Uniq         string
+	InputID      InputID
+	Capabilities map[EvType][]EvCode
+	Properties   []EvProp
+	Modalias     string
+}
+
+func readSysfsString(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(b), "\n"), nil
+}
+
+func readSysfsHex(path string) (uint16, error) {
+	s, err := readSysfsString(path)
+	if err != nil {
+		return 0, err
+	}
+
+	v, err := strconv.ParseUint(s, 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse %s: %v", path, err)
+	}
+
+	return uint16(v), nil
+}
+
+// readUeventFile reads a uevent file from sysfs, which contains one
+// KEY=VALUE pair per line.
+func readUeventFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	env := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		kv := strings.SplitN(scanner.Text(), "=", 2)
+		if len(kv) == 2 {
+			env[kv[0]] = kv[1]
+		}
+	}
+
+	return env, scanner.Err()
+}
+
+// DeviceInfoFromSysfs reads the information of the event device with the given
+// name (e.g. event3) from sysfs.
+func DeviceInfoFromSysfs(eventName string) (*DeviceInfo, error) {
+	return deviceInfoFromSysfs(sysfsInputPath, eventName)
+}
+
+// deviceInfoFromSysfs reads the information of an event device from the
+// input class directory at classPath.
+func deviceInfoFromSysfs(classPath, eventName string) (*DeviceInfo, error) {
+	eventPath := filepath.Join(classPath, eventName)
+
+	devPath, err := filepath.EvalSymlinks(filepath.Join(eventPath, "device"))
+	if err != nil {
+		return nil, err
+	}
+
+	info := &DeviceInfo{
+		Path:      filepath.Join("/dev/input", eventName),
+		SysfsPath: devPath,
+	}
+
+	if env, err := readUeventFile(filepath.Join(eventPath, "uevent")); err == nil {
+		if devName, ok := env["DEVNAME"]; ok {
+			info.Path = filepath.Join("/dev", devName)
+		}
+	}
+
+	if info.Name, err = readSysfsString(filepath.Join(devPath, "name")); err != nil {
+		return nil, err
+	}
+
+	// phys and uniq are empty for many devices
+	info.Phys, _ = readSysfsString(filepath.Join(devPath, "phys"))
+	info.Uniq, _ = readSysfsString(filepath.Join(devPath, "uniq"))
+	info.Modalias, _ = readSysfsString(filepath.Join(devPath, "modalias"))
+
+	ids := []struct {
+		name  string
+		value *uint16
+	}{
+		{"bustype", &info.InputID.BusType},
+		{"vendor", &info.InputID.Vendor},
+		{"product", &info.InputID.Product},
+		{"version", &info.InputID.Version},
+	}
+
+	for _, id := range ids {
+		if *id.value, err = readSysfsHex(filepath.Join(devPath, "id", id.name)); err != nil {
+			return nil, err
+		}
+	}
+
+	info.Capabilities, info.Properties, err = parseCapabilities(func(name string) (string, bool) {
+		path := filepath.Join(devPath, "capabilities", name)
+		if name == "prop" {
+			path = filepath.Join(devPath, "properties")
+		}
+
+		s, err := readSysfsString(path)
+		return s, err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// ListDeviceInfos lists all input devices that have an event device node,
+// reading their information from sysfs. Unlike ListDevicePaths, it does not
+// open the device nodes and hence does not need any permissions on them.
+func ListDeviceInfos() ([]DeviceInfo, error) {
+	return listDeviceInfos(sysfsInputPath)
+}
+
+func listDeviceInfos(classPath string) ([]DeviceInfo, error) {
+	var list []DeviceInfo
+
+	entries, err := os.ReadDir(classPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if !isEventNode(entry.Name()) {
+			continue
+		}
+
+		if info, err := deviceInfoFromSysfs(classPath, entry.Name()); err == nil {
+			list = append(list, *info)
+		}
+	}
+
+	return list, nil
+}
+
+// HasCode returns true if the device supports the given event code of the given type.
+func (info *DeviceInfo) HasCode(t EvType, code EvCode) bool {
+	for _, c := range info.Capabilities[t] {
+		if c == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasProperty returns true if the device has the given property.
+func (info *DeviceInfo) HasProperty(p EvProp) bool {
+	for _, prop := range info.Properties {
+		if prop == p {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,155 @@
+package evdev
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fakeSysfsDevice describes an input device in a fake sysfs tree.
+type fakeSysfsDevice struct {
+	event string // name of the event node, e.g. event3
+	name  string
+	phys  string
+	uniq  string
+	id    InputID
+
+	devname      string            // DEVNAME in the uevent file, input/<event> if empty
+	capabilities map[string]string // bitmasks in capabilities/, ev is 3 if unset
+	properties   string
+	modalias     string
+}
+
+// writeFakeSysfs creates the input class directory of a fake sysfs tree with
+// the given devices and returns its path.
+func writeFakeSysfs(t *testing.T, devices []fakeSysfsDevice) string {
+	root := t.TempDir()
+	classPath := filepath.Join(root, "class", "input")
+
+	write := func(path, content string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, d := range devices {
+		devPath := filepath.Join(root, "devices", fmt.Sprintf("input%d", i))
+
+		write(filepath.Join(devPath, "name"), d.name)
+		write(filepath.Join(devPath, "phys"), d.phys)
+		write(filepath.Join(devPath, "uniq"), d.uniq)
+		write(filepath.Join(devPath, "id", "bustype"), fmt.Sprintf("%04x", d.id.BusType))
+		write(filepath.Join(devPath, "id", "vendor"), fmt.Sprintf("%04x", d.id.Vendor))
+		write(filepath.Join(devPath, "id", "product"), fmt.Sprintf("%04x", d.id.Product))
+		write(filepath.Join(devPath, "id", "version"), fmt.Sprintf("%04x", d.id.Version))
+
+		if d.capabilities == nil {
+			write(filepath.Join(devPath, "capabilities", "ev"), "3")
+		}
+		for name, mask := range d.capabilities {
+			write(filepath.Join(devPath, "capabilities", name), mask)
+		}
+
+		if d.properties != "" {
+			write(filepath.Join(devPath, "properties"), d.properties)
+		}
+		if d.modalias != "" {
+			write(filepath.Join(devPath, "modalias"), d.modalias)
+		}
+
+		devname := d.devname
+		if devname == "" {
+			devname = "input/" + d.event
+		}
+
+		eventPath := filepath.Join(classPath, d.event)
+		write(filepath.Join(eventPath, "uevent"), "MAJOR=13\nDEVNAME="+devname)
+
+		if err := os.Symlink(devPath, filepath.Join(eventPath, "device")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return classPath
+}
+
+func Test_deviceInfoFromSysfs(t *testing.T) {
+	touchpad := fakeSysfsDevice{
+		event:   "event5",
+		devname: "input/by-fake/touchpad",
+		name:    "SYNA8004:00 06CB:CD8B Touchpad",
+		phys:    "i2c-SYNA8004:00",
+		id:      InputID{BusType: BUS_I2C, Vendor: 0x06cb, Product: 0xcd8b, Version: 0x0100},
+		capabilities: map[string]string{
+			"ev":  formatBitmask(int(EV_SYN), int(EV_KEY), int(EV_ABS)),
+			"key": formatBitmask(int(BTN_LEFT), int(BTN_TOOL_FINGER), int(BTN_TOUCH)),
+			"abs": formatBitmask(int(ABS_X), int(ABS_Y), int(ABS_MT_SLOT), int(ABS_MT_POSITION_X)),
+			"rel": "0",
+		},
+		properties: formatBitmask(int(INPUT_PROP_POINTER), int(INPUT_PROP_BUTTONPAD)),
+		modalias:   "input:b0018v06CBpCD8Be0100-e0,1,3,k110,145,14A,ra0,1,2F,35,",
+	}
+
+	classPath := writeFakeSysfs(t, []fakeSysfsDevice{touchpad})
+
+	got, err := deviceInfoFromSysfs(classPath, "event5")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &DeviceInfo{
+		Path:      "/dev/input/by-fake/touchpad",
+		SysfsPath: filepath.Join(filepath.Dir(filepath.Dir(classPath)), "devices", "input0"),
+		Name:      touchpad.name,
+		Phys:      touchpad.phys,
+		InputID:   touchpad.id,
+		Capabilities: map[EvType][]EvCode{
+			EV_SYN: nil,
+			EV_KEY: {BTN_LEFT, BTN_TOOL_FINGER, BTN_TOUCH},
+			EV_ABS: {ABS_X, ABS_Y, ABS_MT_SLOT, ABS_MT_POSITION_X},
+		},
+		Properties: []EvProp{INPUT_PROP_POINTER, INPUT_PROP_BUTTONPAD},
+		Modalias:   touchpad.modalias,
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("deviceInfoFromSysfs() = %+v, want %+v", got, want)
+	}
+
+	if _, err := deviceInfoFromSysfs(classPath, "event6"); err == nil {
+		t.Error("deviceInfoFromSysfs() of missing device succeeded")
+	}
+}
+
+func Test_listDeviceInfos(t *testing.T) {
+	classPath := writeFakeSysfs(t, []fakeSysfsDevice{
+		{event: "event3", name: "AT Translated Set 2 keyboard"},
+		{event: "event7", name: "Logitech USB Optical Mouse"},
+	})
+
+	// not event devices
+	for _, name := range []string{"input3", "mouse0"} {
+		if err := os.Mkdir(filepath.Join(classPath, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	infos, err := listDeviceInfos(classPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	for _, info := range infos {
+		paths = append(paths, info.Path)
+	}
+
+	if want := []string{"/dev/input/event3", "/dev/input/event7"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("listDeviceInfos() paths = %v, want %v", paths, want)
+	}
+}
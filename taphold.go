@@ -0,0 +1,251 @@
+package evdev
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"time"
+)
+
+// TapHoldMode controls how a dual-role key decides between tap and hold when
+// other keys are pressed before the tapping term expired. The modes follow
+// the options of the same name in QMK.
+type TapHoldMode int
+
+const (
+	// TapHoldDefault decides on hold only once the tapping term expired.
+	// Releasing the key before that always results in a tap.
+	TapHoldDefault TapHoldMode = iota
+	// TapHoldPermissiveHold decides on hold as soon as another key is pressed
+	// and released while the dual-role key is held.
+	TapHoldPermissiveHold
+	// TapHoldHoldOnOtherKeyPress decides on hold as soon as another key is
+	// pressed while the dual-role key is held.
+	TapHoldHoldOnOtherKeyPress
+)
+
+// DefaultTappingTerm is the tapping term used if none is configured.
+const DefaultTappingTerm = 200 * time.Millisecond
+
+// TapHoldKey describes a dual-role key: tapping Key emits Tap, holding it emits Hold.
+type TapHoldKey struct {
+	Key  EvCode
+	Tap  EvCode
+	Hold EvCode
+}
+
+// TapHoldConfig describes the behaviour of a TapHold engine.
+type TapHoldConfig struct {
+	Keys        []TapHoldKey
+	TappingTerm time.Duration // DefaultTappingTerm if zero
+	Mode        TapHoldMode
+}
+
+// TapHold implements dual-role keys (e.g. CapsLock acting as Esc when tapped
+// and as Ctrl when held) on top of an event stream.
+//
+// While a dual-role key is undecided, all following events are held back.
+// Once the decision is made, the key's tap or hold code is emitted, followed
+// by the held back events. Held back events that are older than the emitted
+// code get its timestamp, so timestamps never go backwards.
+type TapHold struct {
+	src    *InputDevice
+	dst    *VirtualDevice
+	config TapHoldConfig
+	keys   map[EvCode]TapHoldKey
+
+	pending *tapHoldPending
+	decided map[EvCode]EvCode
+}
+
+type tapHoldPending struct {
+	key         TapHoldKey
+	time        syscall.Timeval
+	buffer      []InputEvent
+	interrupted map[EvCode]bool
+}
+
+// NewTapHold creates a new TapHold engine that reads events from src and
+// writes the result to dst, which is typically created with CreateDevice and
+// must support the tap and hold codes of all keys. src should be grabbed by the
+// caller so the original events do not reach other clients. src may be nil if
+// events are only fed in using HandleEvent.
+func NewTapHold(src *InputDevice, dst *VirtualDevice, config TapHoldConfig) *TapHold {
+	if config.TappingTerm == 0 {
+		config.TappingTerm = DefaultTappingTerm
+	}
+
+	t := &TapHold{
+		src:     src,
+		dst:     dst,
+		config:  config,
+		keys:    make(map[EvCode]TapHoldKey),
+		decided: make(map[EvCode]EvCode),
+	}
+
+	for _, k := range config.Keys {
+		t.keys[k.Key] = k
+	}
+
+	return t
+}
+
+func timevalTime(tv syscall.Timeval) time.Time {
+	return time.Unix(0, tv.Nano())
+}
+
+func timeTimeval(t time.Time) syscall.Timeval {
+	return syscall.NsecToTimeval(t.UnixNano())
+}
+
+// Deadline returns the point in time at which the undecided dual-role key, if
+// any, turns into a hold. The boolean is false if no key is undecided.
+func (t *TapHold) Deadline() (time.Time, bool) {
+	if t.pending == nil {
+		return time.Time{}, false
+	}
+
+	return timevalTime(t.pending.time).Add(t.config.TappingTerm), true
+}
+
+// Timeout must be called when the deadline returned by Deadline passed without
+// any further events. It returns the events to emit.
+func (t *TapHold) Timeout(now time.Time) []InputEvent {
+	deadline, ok := t.Deadline()
+	if !ok || now.Before(deadline) {
+		return nil
+	}
+
+	return t.hold(timeTimeval(deadline))
+}
+
+func keyEvent(time syscall.Timeval, code EvCode, value int32) InputEvent {
+	return InputEvent{Time: time, Type: EV_KEY, Code: code, Value: value}
+}
+
+func synEvent(time syscall.Timeval) InputEvent {
+	return InputEvent{Time: time, Type: EV_SYN, Code: SYN_REPORT}
+}
+
+// resolve ends the undecided state and feeds the held back events through
+// the engine again.
+func (t *TapHold) resolve(events []InputEvent) []InputEvent {
+	buffer := t.pending.buffer
+	t.pending = nil
+
+	// The decision was reported in a frame of its own
+	if len(buffer) > 0 && buffer[0].Type == EV_SYN && buffer[0].Code == SYN_REPORT {
+		buffer = buffer[1:]
+	}
+
+	decision := timevalTime(events[0].Time)
+
+	for _, e := range buffer {
+		if timevalTime(e.Time).Before(decision) {
+			e.Time = events[0].Time
+		}
+		events = append(events, t.HandleEvent(e)...)
+	}
+
+	return events
+}
+
+func (t *TapHold) hold(time syscall.Timeval) []InputEvent {
+	key := t.pending.key
+	t.decided[key.Key] = key.Hold
+
+	return t.resolve([]InputEvent{keyEvent(time, key.Hold, 1), synEvent(time)})
+}
+
+// HandleEvent feeds one event into the engine and returns the events to emit.
+func (t *TapHold) HandleEvent(event InputEvent) []InputEvent {
+	if p := t.pending; p != nil {
+		if deadline, _ := t.Deadline(); !timevalTime(event.Time).Before(deadline) {
+			return append(t.hold(timeTimeval(deadline)), t.HandleEvent(event)...)
+		}
+
+		if event.Type == EV_KEY && event.Code == p.key.Key {
+			switch event.Value {
+			case 0:
+				// released within the tapping term
+				events := []InputEvent{keyEvent(p.time, p.key.Tap, 1), synEvent(p.time)}
+				events = t.resolve(events)
+
+				return append(events, keyEvent(event.Time, p.key.Tap, 0))
+			default:
+				// autorepeat of the undecided key is meaningless
+				return nil
+			}
+		}
+
+		if event.Type == EV_KEY {
+			switch {
+			case event.Value == 1 && t.config.Mode == TapHoldHoldOnOtherKeyPress:
+				return append(t.hold(event.Time), t.HandleEvent(event)...)
+			case event.Value == 0 && t.config.Mode == TapHoldPermissiveHold && p.interrupted[event.Code]:
+				return append(t.hold(event.Time), t.HandleEvent(event)...)
+			case event.Value == 1:
+				p.interrupted[event.Code] = true
+			}
+		}
+
+		p.buffer = append(p.buffer, event)
+
+		return nil
+	}
+
+	if event.Type != EV_KEY {
+		return []InputEvent{event}
+	}
+
+	if code, ok := t.decided[event.Code]; ok {
+		if event.Value == 0 {
+			delete(t.decided, event.Code)
+		}
+
+		event.Code = code
+
+		return []InputEvent{event}
+	}
+
+	if key, ok := t.keys[event.Code]; ok && event.Value == 1 {
+		t.pending = &tapHoldPending{
+			key:         key,
+			time:        event.Time,
+			interrupted: make(map[EvCode]bool),
+		}
+
+		return nil
+	}
+
+	return []InputEvent{event}
+}
+
+// Run reads events from the source device, processes them and writes the
+// result to the destination device until the context is done or an error occurs.
+func (t *TapHold) Run(ctx context.Context) error {
+	for {
+		readCtx, cancel := ctx, context.CancelFunc(func() {})
+		if deadline, ok := t.Deadline(); ok {
+			readCtx, cancel = context.WithDeadline(ctx, deadline)
+		}
+
+		event, err := t.src.ReadContext(readCtx)
+		cancel()
+
+		var events []InputEvent
+
+		switch {
+		case err == nil:
+			events = t.HandleEvent(*event)
+		case errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil:
+			events = t.Timeout(time.Now())
+		default:
+			return err
+		}
+
+		if err := t.dst.WriteEvents(events); err != nil {
+			return err
+		}
+	}
+}
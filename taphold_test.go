@@ -0,0 +1,119 @@
+package evdev
+
+import (
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func tapHoldTime(ms int64) syscall.Timeval {
+	return syscall.NsecToTimeval((time.Duration(ms) * time.Millisecond).Nanoseconds())
+}
+
+func tapHoldKeyEvent(ms int64, code EvCode, value int32) InputEvent {
+	return keyEvent(tapHoldTime(ms), code, value)
+}
+
+func tapHoldSyn(ms int64) InputEvent {
+	return synEvent(tapHoldTime(ms))
+}
+
+func TestTapHold(t *testing.T) {
+	keys := []TapHoldKey{{Key: KEY_CAPSLOCK, Tap: KEY_ESC, Hold: KEY_LEFTCTRL}}
+
+	tests := []struct {
+		name  string
+		mode  TapHoldMode
+		input []InputEvent
+		want  []InputEvent
+	}{
+		{
+			name: "tap",
+			input: []InputEvent{
+				tapHoldKeyEvent(0, KEY_CAPSLOCK, 1), tapHoldSyn(0),
+				tapHoldKeyEvent(50, KEY_CAPSLOCK, 0), tapHoldSyn(50),
+			},
+			want: []InputEvent{
+				tapHoldKeyEvent(0, KEY_ESC, 1), tapHoldSyn(0),
+				tapHoldKeyEvent(50, KEY_ESC, 0), tapHoldSyn(50),
+			},
+		},
+		{
+			name: "hold after tapping term",
+			input: []InputEvent{
+				tapHoldKeyEvent(0, KEY_CAPSLOCK, 1), tapHoldSyn(0),
+				tapHoldKeyEvent(300, KEY_C, 1), tapHoldSyn(300),
+				tapHoldKeyEvent(350, KEY_C, 0), tapHoldSyn(350),
+				tapHoldKeyEvent(400, KEY_CAPSLOCK, 2), tapHoldSyn(400),
+				tapHoldKeyEvent(450, KEY_CAPSLOCK, 0), tapHoldSyn(450),
+			},
+			want: []InputEvent{
+				tapHoldKeyEvent(200, KEY_LEFTCTRL, 1), tapHoldSyn(200),
+				tapHoldKeyEvent(300, KEY_C, 1), tapHoldSyn(300),
+				tapHoldKeyEvent(350, KEY_C, 0), tapHoldSyn(350),
+				tapHoldKeyEvent(400, KEY_LEFTCTRL, 2), tapHoldSyn(400),
+				tapHoldKeyEvent(450, KEY_LEFTCTRL, 0), tapHoldSyn(450),
+			},
+		},
+		{
+			name: "roll over is a tap by default",
+			input: []InputEvent{
+				tapHoldKeyEvent(0, KEY_CAPSLOCK, 1), tapHoldSyn(0),
+				tapHoldKeyEvent(50, KEY_C, 1), tapHoldSyn(50),
+				tapHoldKeyEvent(100, KEY_C, 0), tapHoldSyn(100),
+				tapHoldKeyEvent(150, KEY_CAPSLOCK, 0), tapHoldSyn(150),
+			},
+			want: []InputEvent{
+				tapHoldKeyEvent(0, KEY_ESC, 1), tapHoldSyn(0),
+				tapHoldKeyEvent(50, KEY_C, 1), tapHoldSyn(50),
+				tapHoldKeyEvent(100, KEY_C, 0), tapHoldSyn(100),
+				tapHoldKeyEvent(150, KEY_ESC, 0), tapHoldSyn(150),
+			},
+		},
+		{
+			name: "permissive hold",
+			mode: TapHoldPermissiveHold,
+			input: []InputEvent{
+				tapHoldKeyEvent(0, KEY_CAPSLOCK, 1), tapHoldSyn(0),
+				tapHoldKeyEvent(50, KEY_C, 1), tapHoldSyn(50),
+				tapHoldKeyEvent(100, KEY_C, 0), tapHoldSyn(100),
+				tapHoldKeyEvent(150, KEY_CAPSLOCK, 0), tapHoldSyn(150),
+			},
+			want: []InputEvent{
+				tapHoldKeyEvent(100, KEY_LEFTCTRL, 1), tapHoldSyn(100),
+				tapHoldKeyEvent(100, KEY_C, 1), tapHoldSyn(100),
+				tapHoldKeyEvent(100, KEY_C, 0), tapHoldSyn(100),
+				tapHoldKeyEvent(150, KEY_LEFTCTRL, 0), tapHoldSyn(150),
+			},
+		},
+		{
+			name: "hold on other key press",
+			mode: TapHoldHoldOnOtherKeyPress,
+			input: []InputEvent{
+				tapHoldKeyEvent(0, KEY_CAPSLOCK, 1), tapHoldSyn(0),
+				tapHoldKeyEvent(50, KEY_C, 1), tapHoldSyn(50),
+				tapHoldKeyEvent(100, KEY_CAPSLOCK, 0), tapHoldSyn(100),
+			},
+			want: []InputEvent{
+				tapHoldKeyEvent(50, KEY_LEFTCTRL, 1), tapHoldSyn(50),
+				tapHoldKeyEvent(50, KEY_C, 1), tapHoldSyn(50),
+				tapHoldKeyEvent(100, KEY_LEFTCTRL, 0), tapHoldSyn(100),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th := NewTapHold(nil, nil, TapHoldConfig{Keys: keys, Mode: tt.mode})
+
+			var got []InputEvent
+			for _, e := range tt.input {
+				got = append(got, th.HandleEvent(e)...)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("HandleEvent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
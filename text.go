@@ -0,0 +1,178 @@
+package evdev
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// numLockKeys only produce symbols while NumLock is on.
+var numLockKeys = map[EvCode]bool{
+	KEY_KP0: true, KEY_KP1: true, KEY_KP2: true, KEY_KP3: true, KEY_KP4: true,
+	KEY_KP5: true, KEY_KP6: true, KEY_KP7: true, KEY_KP8: true, KEY_KP9: true,
+	KEY_KPDOT: true,
+}
+
+// TextDecoder converts key events to text using a KeyboardLayout. It tracks
+// the state of Shift, AltGr, CapsLock and NumLock and composes dead keys with
+// the following character.
+//
+// Keys pressed while Ctrl, Alt or Meta is held do not produce text.
+// Autorepeat events produce text like key presses.
+type TextDecoder struct {
+	d      *InputDevice
+	layout *KeyboardLayout
+
+	modifiers map[EvCode]bool
+	capsLock  bool
+	numLock   bool
+	dead      rune
+	pending   []rune
+}
+
+// NewTextDecoder creates a new TextDecoder that reads events from the given
+// device, which may be nil if events are only fed in using HandleEvent.
+func NewTextDecoder(d *InputDevice, layout *KeyboardLayout) *TextDecoder {
+	return &TextDecoder{
+		d:         d,
+		layout:    layout,
+		modifiers: make(map[EvCode]bool),
+	}
+}
+
+// SetLocks sets the state of CapsLock and NumLock.
+func (t *TextDecoder) SetLocks(capsLock, numLock bool) {
+	t.capsLock = capsLock
+	t.numLock = numLock
+}
+
+// SyncLocks reads the state of CapsLock and NumLock from the LEDs of the device.
+func (t *TextDecoder) SyncLocks() error {
+	leds, err := t.d.State(EV_LED)
+	if err != nil {
+		return err
+	}
+
+	t.SetLocks(leds[LED_CAPSL], leds[LED_NUML])
+
+	return nil
+}
+
+func (t *TextDecoder) held(codes ...EvCode) bool {
+	for _, code := range codes {
+		if t.modifiers[code] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HandleEvent feeds one event into the decoder and returns the text it
+// produced, which may be empty or, after a dead key, more than one rune.
+func (t *TextDecoder) HandleEvent(event *InputEvent) []rune {
+	if event.Type != EV_KEY {
+		return nil
+	}
+
+	switch event.Code {
+	case KEY_LEFTSHIFT, KEY_RIGHTSHIFT, KEY_RIGHTALT,
+		KEY_LEFTCTRL, KEY_RIGHTCTRL, KEY_LEFTALT, KEY_LEFTMETA, KEY_RIGHTMETA:
+		if event.Value == 0 {
+			delete(t.modifiers, event.Code)
+		} else {
+			t.modifiers[event.Code] = true
+		}
+		return nil
+	case KEY_CAPSLOCK:
+		if event.Value == 1 {
+			t.capsLock = !t.capsLock
+		}
+		return nil
+	case KEY_NUMLOCK:
+		if event.Value == 1 {
+			t.numLock = !t.numLock
+		}
+		return nil
+	}
+
+	if event.Value == 0 || t.held(KEY_LEFTCTRL, KEY_RIGHTCTRL, KEY_LEFTALT, KEY_LEFTMETA, KEY_RIGHTMETA) {
+		return nil
+	}
+
+	if numLockKeys[event.Code] && !t.numLock {
+		return nil
+	}
+
+	shift := t.held(KEY_LEFTSHIFT, KEY_RIGHTSHIFT)
+	altGr := t.held(KEY_RIGHTALT)
+
+	sym, ok := t.layout.symbol(event.Code, shift, altGr, t.capsLock)
+	if !ok {
+		return nil
+	}
+
+	if t.dead == 0 {
+		if sym.dead {
+			t.dead = sym.r
+			return nil
+		}
+
+		return []rune{sym.r}
+	}
+
+	accent := t.dead
+	t.dead = 0
+
+	switch {
+	case sym.dead && sym.r == accent, sym.r == ' ':
+		return []rune{accent}
+	case sym.dead:
+		t.dead = sym.r
+		return []rune{accent}
+	}
+
+	if r, ok := composeDead(accent, sym.r); ok {
+		return []rune{r}
+	}
+
+	return []rune{accent, sym.r}
+}
+
+// ReadRune reads events from the device until a rune is produced. It returns
+// the rune and its size in UTF-8 encoding, so TextDecoder implements
+// io.RuneReader.
+func (t *TextDecoder) ReadRune() (rune, int, error) {
+	for len(t.pending) == 0 {
+		event, err := t.d.ReadOne()
+		if err != nil {
+			return 0, 0, err
+		}
+
+		t.pending = t.HandleEvent(event)
+	}
+
+	r := t.pending[0]
+	t.pending = t.pending[1:]
+
+	return r, utf8.RuneLen(r), nil
+}
+
+// ReadString reads runes from the device until the given delimiter, e.g. the
+// '\n' sent by most barcode scanners, and returns the text including the
+// delimiter.
+func (t *TextDecoder) ReadString(delim rune) (string, error) {
+	var sb strings.Builder
+
+	for {
+		r, _, err := t.ReadRune()
+		if err != nil {
+			return sb.String(), err
+		}
+
+		sb.WriteRune(r)
+
+		if r == delim {
+			return sb.String(), nil
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package evdev
+
+import (
+	"strings"
+	"testing"
+)
+
+// textEvents converts a list of key codes to press and release events.
+func textEvents(codes ...EvCode) []InputEvent {
+	var events []InputEvent
+
+	for _, code := range codes {
+		events = append(events,
+			InputEvent{Type: EV_KEY, Code: code, Value: 1},
+			InputEvent{Type: EV_KEY, Code: code, Value: 0})
+	}
+
+	return events
+}
+
+func textChord(modifier EvCode, codes ...EvCode) []InputEvent {
+	events := []InputEvent{{Type: EV_KEY, Code: modifier, Value: 1}}
+	events = append(events, textEvents(codes...)...)
+
+	return append(events, InputEvent{Type: EV_KEY, Code: modifier, Value: 0})
+}
+
+func concatEvents(lists ...[]InputEvent) []InputEvent {
+	var events []InputEvent
+	for _, l := range lists {
+		events = append(events, l...)
+	}
+
+	return events
+}
+
+func TestTextDecoder(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout *KeyboardLayout
+		input  []InputEvent
+		want   string
+	}{
+		{
+			name:   "us",
+			layout: LayoutUS,
+			input: concatEvents(
+				textChord(KEY_LEFTSHIFT, KEY_H),
+				textEvents(KEY_I, KEY_SPACE),
+				textChord(KEY_RIGHTSHIFT, KEY_2, KEY_SLASH),
+				textEvents(KEY_ENTER),
+			),
+			want: "Hi @?\n",
+		},
+		{
+			name:   "caps lock",
+			layout: LayoutUS,
+			input: concatEvents(
+				textEvents(KEY_CAPSLOCK, KEY_A, KEY_1),
+				textChord(KEY_LEFTSHIFT, KEY_B),
+			),
+			want: "A1b",
+		},
+		{
+			name:   "num lock",
+			layout: LayoutUS,
+			input:  textEvents(KEY_KP1, KEY_NUMLOCK, KEY_KP2, KEY_KPPLUS),
+			want:   "2+",
+		},
+		{
+			name:   "ctrl",
+			layout: LayoutUS,
+			input:  concatEvents(textChord(KEY_LEFTCTRL, KEY_C), textEvents(KEY_C)),
+			want:   "c",
+		},
+		{
+			name:   "de",
+			layout: LayoutDE,
+			input: concatEvents(
+				textEvents(KEY_Y, KEY_APOSTROPHE),
+				textChord(KEY_RIGHTALT, KEY_Q, KEY_E),
+				textChord(KEY_LEFTSHIFT, KEY_SEMICOLON),
+			),
+			want: "zä@€Ö",
+		},
+		{
+			name:   "dead keys",
+			layout: LayoutDE,
+			input: concatEvents(
+				textEvents(KEY_EQUAL, KEY_E),
+				textEvents(KEY_GRAVE, KEY_SPACE),
+				textEvents(KEY_GRAVE, KEY_X),
+				textChord(KEY_LEFTSHIFT, KEY_EQUAL),
+				textEvents(KEY_A),
+			),
+			want: "é^^xà",
+		},
+		{
+			name:   "fr",
+			layout: LayoutFR,
+			input: concatEvents(
+				textEvents(KEY_Q, KEY_2),
+				textChord(KEY_LEFTSHIFT, KEY_2),
+				textEvents(KEY_LEFTBRACE, KEY_O),
+			),
+			want: "aé2ô",
+		},
+		{
+			name:   "uk",
+			layout: LayoutUK,
+			input:  textChord(KEY_LEFTSHIFT, KEY_3, KEY_APOSTROPHE, KEY_102ND),
+			want:   "£@|",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewTextDecoder(nil, tt.layout)
+
+			var got []rune
+			for i := range tt.input {
+				got = append(got, d.HandleEvent(&tt.input[i])...)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("HandleEvent() = %q, want %q", string(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseKeyboardLayout(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{name: "valid", input: "# comment\nKEY_A a\nKEY_1 1 ! ¹ U+00A1\nKEY_EQUAL dead_acute none"},
+		{name: "unknown key", input: "KEY_FOO a", wantErr: true},
+		{name: "invalid symbol", input: "KEY_A ab", wantErr: true},
+		{name: "too many symbols", input: "KEY_A a A b B c", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseKeyboardLayout(tt.name, strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseKeyboardLayout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyboardLayoutStrokes(t *testing.T) {
+	tests := []struct {
+		layout *KeyboardLayout
+		text   string
+	}{
+		{layout: LayoutUS, text: "Hello, World! ~`@#$%^&*()_+{}|:\"<>?\n"},
+		{layout: LayoutUK, text: "£@~#|\\€"},
+		{layout: LayoutDE, text: "Hello, Welt! äöüß@€{}[]\\~|^°é è"},
+		{layout: LayoutFR, text: "Bonjour à tous! ç@ ô ë ñ"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.layout.Name, func(t *testing.T) {
+			d := NewTextDecoder(nil, tt.layout)
+
+			var got []rune
+			for _, r := range tt.text {
+				strokes, ok := tt.layout.strokes(r)
+				if !ok {
+					t.Fatalf("strokes(%q) failed", r)
+				}
+
+				for _, s := range strokes {
+					chord := s.chord()
+
+					var events []InputEvent
+					for _, code := range chord {
+						events = append(events, InputEvent{Type: EV_KEY, Code: code, Value: 1})
+					}
+					for i := len(chord) - 1; i >= 0; i-- {
+						events = append(events, InputEvent{Type: EV_KEY, Code: chord[i], Value: 0})
+					}
+
+					for i := range events {
+						got = append(got, d.HandleEvent(&events[i])...)
+					}
+				}
+			}
+
+			if string(got) != tt.text {
+				t.Errorf("round trip = %q, want %q", string(got), tt.text)
+			}
+		})
+	}
+
+	if _, ok := LayoutUS.strokes('ä'); ok {
+		t.Errorf("strokes('ä') succeeded for US layout")
+	}
+}
@@ -0,0 +1,244 @@
+package evdev
+
+import (
+	"fmt"
+)
+
+// Contact describes a single contact on a multitouch device.
+type Contact struct {
+	Slot       int   // the slot the contact is reported in
+	ID         int32 // the tracking ID assigned by the kernel
+	X          int32
+	Y          int32
+	Pressure   int32
+	TouchMajor int32
+	TouchMinor int32
+	ToolType   int32 // one of MT_TOOL_*
+}
+
+// TouchTracker follows the contacts of a multitouch device that implements
+// the ABS_MT protocol B (slots). It consumes events, and for every SYN_REPORT
+// it updates its snapshot of active contacts and calls OnBegin, OnMove and
+// OnEnd for the contacts that appeared, moved or disappeared.
+type TouchTracker struct {
+	// OnBegin is called when a new contact appears.
+	OnBegin func(c Contact)
+	// OnMove is called when a contact changed any of its properties.
+	OnMove func(c Contact)
+	// OnEnd is called with the last known state of a contact that disappeared.
+	OnEnd func(c Contact)
+
+	dev     *InputDevice
+	src     stateSource
+	codes   map[EvCode]bool
+	current []Contact
+	frame   []Contact
+	slot    int
+	dropped bool
+	err     error
+}
+
+var touchCodes = []EvCode{
+	ABS_MT_TRACKING_ID,
+	ABS_MT_POSITION_X,
+	ABS_MT_POSITION_Y,
+	ABS_MT_PRESSURE,
+	ABS_MT_TOUCH_MAJOR,
+	ABS_MT_TOUCH_MINOR,
+	ABS_MT_TOOL_TYPE,
+}
+
+// NewTouchTracker creates a new TouchTracker for the given device.
+// The contacts already present on the device are read at creation time.
+// Returns an error if the device does not support multitouch slots.
+func NewTouchTracker(d *InputDevice) (*TouchTracker, error) {
+	t, err := newTouchTracker(d)
+	if err != nil {
+		return nil, err
+	}
+
+	t.dev = d
+
+	return t, nil
+}
+
+func newTouchTracker(src stateSource) (*TouchTracker, error) {
+	absInfos, err := src.AbsInfos()
+	if err != nil {
+		return nil, err
+	}
+
+	slotInfo, ok := absInfos[ABS_MT_SLOT]
+	if !ok {
+		return nil, fmt.Errorf("device does not support multitouch slots")
+	}
+
+	t := &TouchTracker{
+		src:     src,
+		codes:   make(map[EvCode]bool),
+		current: make([]Contact, slotInfo.Maximum+1),
+	}
+
+	for _, code := range touchCodes {
+		if _, ok := absInfos[code]; ok {
+			t.codes[code] = true
+		}
+	}
+
+	if err := t.seed(); err != nil {
+		return nil, err
+	}
+
+	t.frame = make([]Contact, len(t.current))
+	copy(t.frame, t.current)
+
+	return t, nil
+}
+
+// seed reads the contacts and the selected slot from the device. The tracked
+// state is left untouched if that fails.
+func (t *TouchTracker) seed() error {
+	absInfos, err := t.src.AbsInfos()
+	if err != nil {
+		return err
+	}
+
+	contacts := make([]Contact, len(t.current))
+	for i := range contacts {
+		contacts[i] = Contact{Slot: i, ID: -1}
+	}
+
+	for code := range t.codes {
+		values, err := t.src.MTSlotValues(code)
+		if err != nil {
+			return fmt.Errorf("cannot get slot values: %v", err)
+		}
+
+		for i, value := range values {
+			if i < len(contacts) {
+				contacts[i].set(code, value)
+			}
+		}
+	}
+
+	copy(t.current, contacts)
+	t.slot = int(absInfos[ABS_MT_SLOT].Value)
+
+	return nil
+}
+
+func (c *Contact) set(code EvCode, value int32) {
+	switch code {
+	case ABS_MT_TRACKING_ID:
+		c.ID = value
+	case ABS_MT_POSITION_X:
+		c.X = value
+	case ABS_MT_POSITION_Y:
+		c.Y = value
+	case ABS_MT_PRESSURE:
+		c.Pressure = value
+	case ABS_MT_TOUCH_MAJOR:
+		c.TouchMajor = value
+	case ABS_MT_TOUCH_MINOR:
+		c.TouchMinor = value
+	case ABS_MT_TOOL_TYPE:
+		c.ToolType = value
+	}
+}
+
+// Contacts returns the active contacts as of the last complete frame,
+// ordered by slot.
+func (t *TouchTracker) Contacts() []Contact {
+	var contacts []Contact
+
+	for _, c := range t.frame {
+		if c.ID != -1 {
+			contacts = append(contacts, c)
+		}
+	}
+
+	return contacts
+}
+
+// Err returns the error that occurred when re-reading the contacts from the
+// device after a SYN_DROPPED, or nil. Until that succeeds on a later
+// SYN_REPORT, events are discarded and no frames are completed.
+func (t *TouchTracker) Err() error {
+	return t.err
+}
+
+// HandleEvent feeds one event into the tracker. Events of types other than
+// EV_ABS and EV_SYN are ignored. It returns true if the event completed a frame.
+func (t *TouchTracker) HandleEvent(event *InputEvent) bool {
+	switch event.Type {
+	case EV_SYN:
+		switch event.Code {
+		case SYN_DROPPED:
+			t.dropped = true
+		case SYN_REPORT:
+			if t.dropped {
+				// Events got lost, so the only reliable source is the device itself.
+				if t.err = t.seed(); t.err != nil {
+					return false
+				}
+				t.dropped = false
+			}
+
+			t.commit()
+			return true
+		}
+	case EV_ABS:
+		if t.dropped {
+			break
+		}
+
+		if event.Code == ABS_MT_SLOT {
+			t.slot = int(event.Value)
+		} else if t.codes[event.Code] && t.slot >= 0 && t.slot < len(t.current) {
+			t.current[t.slot].set(event.Code, event.Value)
+		}
+	}
+
+	return false
+}
+
+// ReadFrame reads events from the device until the next SYN_REPORT and
+// returns the active contacts of the resulting frame.
+func (t *TouchTracker) ReadFrame() ([]Contact, error) {
+	for {
+		event, err := t.dev.ReadOne()
+		if err != nil {
+			return nil, err
+		}
+
+		if t.HandleEvent(event) {
+			return t.Contacts(), nil
+		}
+
+		if t.err != nil {
+			return nil, t.err
+		}
+	}
+}
+
+func (t *TouchTracker) commit() {
+	for i, c := range t.current {
+		prev := t.frame[i]
+
+		switch {
+		case prev.ID == c.ID:
+			if c.ID != -1 && prev != c && t.OnMove != nil {
+				t.OnMove(c)
+			}
+		default:
+			if prev.ID != -1 && t.OnEnd != nil {
+				t.OnEnd(prev)
+			}
+			if c.ID != -1 && t.OnBegin != nil {
+				t.OnBegin(c)
+			}
+		}
+
+		t.frame[i] = c
+	}
+}
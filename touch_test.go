@@ -0,0 +1,179 @@
+package evdev
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestTouchTrackerSlots(t *testing.T) {
+	slot := func(value int32) InputEvent { return absEvent(ABS_MT_SLOT, value) }
+	id := func(value int32) InputEvent { return absEvent(ABS_MT_TRACKING_ID, value) }
+	x := func(value int32) InputEvent { return absEvent(ABS_MT_POSITION_X, value) }
+	syn := InputEvent{Type: EV_SYN, Code: SYN_REPORT}
+
+	tests := []struct {
+		name     string
+		events   []InputEvent
+		contacts []Contact
+		log      []string
+	}{
+		{
+			name:     "initial contact",
+			events:   []InputEvent{syn},
+			contacts: []Contact{{Slot: 0, ID: 5, X: 40}},
+		},
+		{
+			name:     "move in current slot",
+			events:   []InputEvent{x(45), syn},
+			contacts: []Contact{{Slot: 0, ID: 5, X: 45}},
+			log:      []string{"move 5"},
+		},
+		{
+			name:   "second contact",
+			events: []InputEvent{slot(1), id(6), x(70), syn},
+			contacts: []Contact{
+				{Slot: 0, ID: 5, X: 40},
+				{Slot: 1, ID: 6, X: 70},
+			},
+			log: []string{"begin 6"},
+		},
+		{
+			name:   "events follow the selected slot",
+			events: []InputEvent{slot(1), id(6), syn, x(80), slot(0), x(10), syn},
+			contacts: []Contact{
+				{Slot: 0, ID: 5, X: 10},
+				{Slot: 1, ID: 6, X: 80},
+			},
+			log: []string{"begin 6", "move 5", "move 6"},
+		},
+		{
+			name:     "lift",
+			events:   []InputEvent{id(-1), syn},
+			contacts: nil,
+			log:      []string{"end 5"},
+		},
+		{
+			name:     "replaced in one frame",
+			events:   []InputEvent{id(7), x(0), syn},
+			contacts: []Contact{{Slot: 0, ID: 7}},
+			log:      []string{"end 5", "begin 7"},
+		},
+		{
+			name:     "invalid slot",
+			events:   []InputEvent{slot(5), x(1), syn},
+			contacts: []Contact{{Slot: 0, ID: 5, X: 40}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker, err := newTouchTracker(newFakeTouchpad())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var log []string
+			tracker.OnBegin = func(c Contact) { log = append(log, fmt.Sprintf("begin %d", c.ID)) }
+			tracker.OnMove = func(c Contact) { log = append(log, fmt.Sprintf("move %d", c.ID)) }
+			tracker.OnEnd = func(c Contact) { log = append(log, fmt.Sprintf("end %d", c.ID)) }
+
+			for i := range tt.events {
+				tracker.HandleEvent(&tt.events[i])
+			}
+
+			if got := tracker.Contacts(); !reflect.DeepEqual(got, tt.contacts) {
+				t.Errorf("Contacts() = %v, want %v", got, tt.contacts)
+			}
+
+			if !reflect.DeepEqual(log, tt.log) {
+				t.Errorf("callbacks = %v, want %v", log, tt.log)
+			}
+		})
+	}
+}
+
+func TestTouchTrackerDropped(t *testing.T) {
+	dropped := InputEvent{Type: EV_SYN, Code: SYN_DROPPED}
+	syn := InputEvent{Type: EV_SYN, Code: SYN_REPORT}
+
+	f := newFakeTouchpad()
+
+	tracker, err := newTouchTracker(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name     string
+		change   func()
+		events   []InputEvent
+		complete bool
+		err      bool
+		contacts []Contact
+	}{
+		{
+			name:     "select second slot",
+			events:   []InputEvent{absEvent(ABS_MT_SLOT, 1), syn},
+			complete: true,
+			contacts: []Contact{{Slot: 0, ID: 5, X: 40}},
+		},
+		{
+			name: "events after drop are discarded",
+			change: func() {
+				f.slots[ABS_MT_TRACKING_ID][1] = 6
+				f.slots[ABS_MT_POSITION_X][1] = 70
+				f.setAbs(ABS_MT_SLOT, 0)
+				f.err = errors.New("device gone")
+			},
+			events:   []InputEvent{dropped, absEvent(ABS_MT_POSITION_X, 1), syn},
+			err:      true,
+			contacts: []Contact{{Slot: 0, ID: 5, X: 40}},
+		},
+		{
+			name: "resync reads contacts and slot",
+			change: func() {
+				f.err = nil
+			},
+			events:   []InputEvent{absEvent(ABS_MT_POSITION_X, 2), syn},
+			complete: true,
+			contacts: []Contact{
+				{Slot: 0, ID: 5, X: 40},
+				{Slot: 1, ID: 6, X: 70},
+			},
+		},
+		{
+			name:     "events go to the resynced slot",
+			events:   []InputEvent{absEvent(ABS_MT_POSITION_X, 50), syn},
+			complete: true,
+			contacts: []Contact{
+				{Slot: 0, ID: 5, X: 50},
+				{Slot: 1, ID: 6, X: 70},
+			},
+		},
+	}
+
+	for _, step := range steps {
+		if step.change != nil {
+			step.change()
+		}
+
+		complete := false
+		for i := range step.events {
+			complete = tracker.HandleEvent(&step.events[i])
+		}
+
+		if complete != step.complete {
+			t.Errorf("%s: HandleEvent() = %v, want %v", step.name, complete, step.complete)
+		}
+
+		if err := tracker.Err(); (err != nil) != step.err {
+			t.Errorf("%s: Err() = %v, want error %v", step.name, err, step.err)
+		}
+
+		if got := tracker.Contacts(); !reflect.DeepEqual(got, step.contacts) {
+			t.Errorf("%s: Contacts() = %v, want %v", step.name, got, step.contacts)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package evdev
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// Uevent describes a kernel uevent of the input subsystem.
+//
+// The kernel sends one uevent for the input device itself (e.g. input5),
+// which carries the identification and capabilities, and one for each of
+// its device nodes (e.g. event3), which carries DevName.
+type Uevent struct {
+	Action       string // add, remove, change, ...
+	DevPath      string // path of the device in sysfs, relative to /sys
+	Subsystem    string
+	DevName      string // name of the device node relative to /dev, e.g. input/event3
+	Product      InputID
+	Name         string
+	Phys         string
+	Uniq         string
+	Capabilities map[EvType][]EvCode
+	Properties   []EvProp
+	Env          map[string]string // all variables of the uevent
+}
+
+// SysfsPath returns the absolute sysfs path of the device.
+func (u *Uevent) SysfsPath() string {
+	return "/sys" + u.DevPath
+}
+
+// capabilityKeys maps the names used for the capability bitmasks in
+// uevents and sysfs to their event types
+var capabilityKeys = map[string]EvType{
+	"key": EV_KEY,
+	"rel": EV_REL,
+	"abs": EV_ABS,
+	"msc": EV_MSC,
+	"led": EV_LED,
+	"snd": EV_SND,
+	"ff":  EV_FF,
+	"sw":  EV_SW,
+}
+
+// parseCapabilities builds the capabilities and properties of a device from the
+// bitmasks returned by lookup, which is called with the lower case names
+// "ev", "key", "rel", ... and "prop".
+func parseCapabilities(lookup func(name string) (string, bool)) (map[EvType][]EvCode, []EvProp, error) {
+	capabilities := make(map[EvType][]EvCode)
+
+	if s, ok := lookup("ev"); ok {
+		b, err := parseBitmask(s)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot parse ev bitmask: %v", err)
+		}
+
+		for _, t := range newBitmap(b).setBits() {
+			capabilities[EvType(t)] = nil
+		}
+	}
+
+	for name, t := range capabilityKeys {
+		s, ok := lookup(name)
+		if !ok {
+			continue
+		}
+
+		b, err := parseBitmask(s)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot parse %s bitmask: %v", name, err)
+		}
+
+		for _, code := range newBitmap(b).setBits() {
+			capabilities[t] = append(capabilities[t], EvCode(code))
+		}
+	}
+
+	var props []EvProp
+
+	if s, ok := lookup("prop"); ok {
+		b, err := parseBitmask(s)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot parse prop bitmask: %v", err)
+		}
+
+		for _, p := range newBitmap(b).setBits() {
+			props = append(props, EvProp(p))
+		}
+	}
+
+	return capabilities, props, nil
+}
+
+// parseProduct parses a product string in the format bus/vendor/product/version,
+// with all fields in hexadecimal.
+func parseProduct(s string) (InputID, error) {
+	var values [4]uint16
+
+	fields := strings.Split(s, "/")
+	if len(fields) != len(values) {
+		return InputID{}, fmt.Errorf("invalid product %q", s)
+	}
+
+	for i, f := range fields {
+		v, err := strconv.ParseUint(f, 16, 16)
+		if err != nil {
+			return InputID{}, fmt.Errorf("invalid product %q: %v", s, err)
+		}
+		values[i] = uint16(v)
+	}
+
+	return InputID{
+		BusType: values[0],
+		Vendor:  values[1],
+		Product: values[2],
+		Version: values[3],
+	}, nil
+}
+
+// parseUevent parses a uevent message as sent by the kernel:
+// a header in the format ACTION@DEVPATH followed by KEY=VALUE pairs,
+// all separated by null bytes.
+func parseUevent(msg []byte) (*Uevent, error) {
+	fields := bytes.Split(msg, []byte{0})
+	if len(fields) < 2 || !bytes.Contains(fields[0], []byte("@")) {
+		return nil, errors.New("invalid uevent header")
+	}
+
+	u := &Uevent{
+		Env: make(map[string]string),
+	}
+
+	for _, f := range fields[1:] {
+		kv := strings.SplitN(string(f), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		u.Env[kv[0]] = kv[1]
+	}
+
+	u.Action = u.Env["ACTION"]
+	u.DevPath = u.Env["DEVPATH"]
+	u.Subsystem = u.Env["SUBSYSTEM"]
+	u.DevName = u.Env["DEVNAME"]
+	u.Name = strings.Trim(u.Env["NAME"], "\"")
+	u.Phys = strings.Trim(u.Env["PHYS"], "\"")
+	u.Uniq = strings.Trim(u.Env["UNIQ"], "\"")
+
+	if product, ok := u.Env["PRODUCT"]; ok {
+		id, err := parseProduct(product)
+		if err != nil {
+			return nil, err
+		}
+		u.Product = id
+	}
+
+	var err error
+	u.Capabilities, u.Properties, err = parseCapabilities(func(name string) (string, bool) {
+		s, ok := u.Env[strings.ToUpper(name)]
+		return s, ok
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// UeventListener receives kernel uevents of the input subsystem through a
+// NETLINK_KOBJECT_UEVENT socket. Unlike a Watcher, it does not depend on
+// device nodes being created in /dev.
+type UeventListener struct {
+	fd     int
+	poller *epoller
+	buffer []byte
+
+	readMu sync.Mutex
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewUeventListener creates a new UeventListener.
+func NewUeventListener() (*UeventListener, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK,
+		syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC|syscall.SOCK_NONBLOCK,
+		syscall.NETLINK_KOBJECT_UEVENT)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create netlink socket: %v", err)
+	}
+
+	addr := &syscall.SockaddrNetlink{
+		Family: syscall.AF_NETLINK,
+		Groups: 1, // kernel uevents
+	}
+
+	if err := syscall.Bind(fd, addr); err != nil {
+		_ = syscall.Close(fd)
+		return nil, fmt.Errorf("cannot bind netlink socket: %v", err)
+	}
+
+	p, err := newEpoller()
+	if err != nil {
+		_ = syscall.Close(fd)
+		return nil, err
+	}
+
+	if err := p.add(fd); err != nil {
+		p.close()
+		_ = syscall.Close(fd)
+		return nil, err
+	}
+
+	return &UeventListener{
+		fd:     fd,
+		poller: p,
+		buffer: make([]byte, 16384),
+	}, nil
+}
+
+// Read blocks until a uevent of the input subsystem has been received and returns it.
+// It returns an error if the listener has been closed.
+func (l *UeventListener) Read() (*Uevent, error) {
+	l.readMu.Lock()
+	defer l.readMu.Unlock()
+
+	for {
+		l.mu.Lock()
+		closed := l.closed
+		l.mu.Unlock()
+
+		if closed {
+			return nil, errors.New("listener closed")
+		}
+
+		fds, err := l.poller.wait(-1)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(fds) == 0 {
+			continue
+		}
+
+		n, from, err := syscall.Recvfrom(l.fd, l.buffer, 0)
+		if err == syscall.EAGAIN || err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot receive uevent: %v", err)
+		}
+
+		// Only accept messages sent by the kernel
+		if nl, ok := from.(*syscall.SockaddrNetlink); !ok || nl.Pid != 0 {
+			continue
+		}
+
+		u, err := parseUevent(l.buffer[:n])
+		if err != nil || u.Subsystem != "input" {
+			continue
+		}
+
+		return u, nil
+	}
+}
+
+// Close closes the listener. A concurrent call to Read returns with an error.
+func (l *UeventListener) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	l.mu.Unlock()
+
+	_ = l.poller.wake()
+
+	// wait for a concurrent Read to return
+	l.readMu.Lock()
+	defer l.readMu.Unlock()
+
+	l.poller.close()
+
+	return syscall.Close(l.fd)
+}
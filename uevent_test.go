@@ -0,0 +1,84 @@
+package evdev
+
+import (
+	"math/bits"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// formatBitmask formats the bits like the kernel formats capability bitmasks
+// in uevents and sysfs, as hex words of the size of a long, most significant
+// word first.
+func formatBitmask(bitsSet ...int) string {
+	var words []uint
+
+	for _, bit := range bitsSet {
+		i := bit / bits.UintSize
+		for len(words) <= i {
+			words = append(words, 0)
+		}
+		words[i] |= 1 << (bit % bits.UintSize)
+	}
+
+	fields := make([]string, len(words))
+	for i, word := range words {
+		fields[len(words)-1-i] = strconv.FormatUint(uint64(word), 16)
+	}
+
+	return strings.Join(fields, " ")
+}
+
+func Test_parseUevent(t *testing.T) {
+	msg := strings.Join([]string{
+		"add@/devices/virtual/input/input42",
+		"ACTION=add",
+		"DEVPATH=/devices/virtual/input/input42",
+		"SUBSYSTEM=input",
+		"PRODUCT=3/4711/816/1",
+		"NAME=\"fake-device\"",
+		"PHYS=\"\"",
+		"PROP=0",
+		"EV=7",
+		"KEY=" + formatBitmask(int(BTN_LEFT), int(BTN_RIGHT), int(BTN_MIDDLE)),
+		"REL=3",
+		"MODALIAS=input:b0003v4711p0816e0001-e0,1,2,k110,111,112,r0,1,amlsfw",
+		"SEQNUM=1234",
+	}, "\x00")
+
+	u, err := parseUevent([]byte(msg))
+	if err != nil {
+		t.Fatalf("parseUevent() error = %v", err)
+	}
+
+	if u.Action != "add" || u.Subsystem != "input" || u.Name != "fake-device" {
+		t.Errorf("parseUevent() = %+v", u)
+	}
+
+	if u.SysfsPath() != "/sys/devices/virtual/input/input42" {
+		t.Errorf("SysfsPath() = %s", u.SysfsPath())
+	}
+
+	wantID := InputID{BusType: 0x03, Vendor: 0x4711, Product: 0x0816, Version: 1}
+	if u.Product != wantID {
+		t.Errorf("Product = %+v, want %+v", u.Product, wantID)
+	}
+
+	wantCapabilities := map[EvType][]EvCode{
+		EV_SYN: nil,
+		EV_KEY: {BTN_LEFT, BTN_RIGHT, BTN_MIDDLE},
+		EV_REL: {REL_X, REL_Y},
+	}
+	if !reflect.DeepEqual(u.Capabilities, wantCapabilities) {
+		t.Errorf("Capabilities = %v, want %v", u.Capabilities, wantCapabilities)
+	}
+
+	if got, want := formatBitmask(int(BTN_LEFT)), "10000 0 0 0 0"; bits.UintSize == 64 && got != want {
+		t.Errorf("formatBitmask() = %q, want %q", got, want)
+	}
+
+	if _, err := parseUevent([]byte("libudev\x00garbage")); err == nil {
+		t.Errorf("parseUevent() expected error for invalid header")
+	}
+}
@@ -11,93 +11,338 @@ import (
 const (
 	uinputMaxNameSize = 80
 	absSize           = 64
+
+	// uinputVersionSetup is the first uinput version supporting
+	// UI_DEV_SETUP and UI_ABS_SETUP.
+	uinputVersionSetup = 5
 )
 
+// uinputSetup mirrors struct uinput_setup.
+type uinputSetup struct {
+	ID         InputID
+	Name       [uinputMaxNameSize]byte
+	EffectsMax uint32
+}
+
+// uinputAbsSetup mirrors struct uinput_abs_setup.
+type uinputAbsSetup struct {
+	Code    uint16
+	_       uint16
+	AbsInfo AbsInfo
+}
+
 // CreateDevice creates a device from scratch with the provided capabilities and name
 // If set up fails the device will be removed from the system,
 // once set up it can be removed by calling dev.Close
-func CreateDevice(name string, id InputID, capabilities map[EvType][]EvCode) (*InputDevice, error) {
-	deviceFile, err := os.OpenFile("/dev/uinput", syscall.O_WRONLY|syscall.O_NONBLOCK, 0660)
+func CreateDevice(name string, id InputID, capabilities map[EvType][]EvCode) (*VirtualDevice, error) {
+	return CreateDeviceWithOptions(name, id, capabilities, CreateOptions{})
+}
+
+// CreateDeviceWithAbsInfos creates a device like CreateDevice and sets up the
+// absolute axes with the provided ranges, fuzz, flat and resolution values.
+// Axes in absInfos are added to the EV_ABS capabilities if necessary.
+// The resolution is ignored on kernels older than 4.5.
+func CreateDeviceWithAbsInfos(name string, id InputID, capabilities map[EvType][]EvCode,
+	absInfos map[EvCode]AbsInfo) (*VirtualDevice, error) {
+	return CreateDeviceWithOptions(name, id, capabilities, CreateOptions{AbsInfos: absInfos})
+}
+
+// CreateOptions controls optional properties of a device created with
+// CreateDeviceWithOptions.
+type CreateOptions struct {
+	// AbsInfos set up the absolute axes, see CreateDeviceWithAbsInfos.
+	AbsInfos map[EvCode]AbsInfo
+	// Properties are the INPUT_PROP bits of the device.
+	Properties []EvProp
+	// Phys is the physical location of the device.
+	Phys string
+	// EffectsMax is the number of force-feedback effects clients can upload.
+	// uinput refuses to create devices with EV_FF capabilities without effects,
+	// so 16 is used if it is 0 for such devices.
+	EffectsMax int
+}
+
+// defaultEffectsMax is the number of force-feedback effects of a device
+// created with EV_FF capabilities if none is given.
+const defaultEffectsMax = 16
+
+// CreateDeviceWithOptions creates a device like CreateDevice with the
+// additional properties in options. Force-feedback requests to a device
+// with EV_FF capabilities must be handled with HandleFeedback.
+func CreateDeviceWithOptions(name string, id InputID, capabilities map[EvType][]EvCode,
+	options CreateOptions) (*VirtualDevice, error) {
+	return createDevice(createConfig(name, id, capabilities, options))
+}
+
+// createConfig returns the uinput configuration of a device created from scratch.
+func createConfig(name string, id InputID, capabilities map[EvType][]EvCode, options CreateOptions) uinputConfig {
+	merged := make(map[EvType][]EvCode, len(capabilities)+1)
+	for ev, codes := range capabilities {
+		merged[ev] = codes
+	}
+
+	hasAbs := make(map[EvCode]bool)
+	for _, code := range merged[EV_ABS] {
+		hasAbs[code] = true
+	}
+
+	var absCodes []EvCode
+	for code := range options.AbsInfos {
+		if !hasAbs[code] {
+			absCodes = append(absCodes, code)
+		}
+	}
+
+	if len(absCodes) > 0 {
+		codes := append([]EvCode{}, merged[EV_ABS]...)
+		merged[EV_ABS] = append(codes, sortCodes(absCodes)...)
+	}
+
+	effectsMax := options.EffectsMax
+	if _, ok := merged[EV_FF]; ok && effectsMax <= 0 {
+		effectsMax = defaultEffectsMax
+	}
+
+	return uinputConfig{
+		name:         name,
+		id:           id,
+		capabilities: merged,
+		absInfos:     options.AbsInfos,
+		properties:   options.Properties,
+		phys:         options.Phys,
+		effectsMax:   uint32(effectsMax),
+	}
+}
+
+// uinputConfig describes a device to be created via uinput.
+type uinputConfig struct {
+	name         string
+	id           InputID
+	capabilities map[EvType][]EvCode
+	absInfos     map[EvCode]AbsInfo
+	properties   []EvProp
+	phys         string
+	effectsMax   uint32
+	repeat       *[2]uint32 // delay and period
+
+	driverVersion int32 // of the source device of a clone
+}
+
+func createDevice(config uinputConfig) (*VirtualDevice, error) {
+	// opened for reading as well to receive feedback, see HandleFeedback
+	deviceFile, err := os.OpenFile("/dev/uinput", syscall.O_RDWR|syscall.O_NONBLOCK, 0660)
 	if err != nil {
-		return nil, err
+		return nil, &UinputError{Op: "open /dev/uinput", Err: err}
 	}
 
 	newDev := &InputDevice{
-		file: deviceFile,
+		file:          deviceFile,
+		driverVersion: config.driverVersion,
 	}
 
-	for ev, codes := range capabilities {
+	fail := func(op string, err error) (*VirtualDevice, error) {
+		_ = ioctlUIDEVDESTROY(newDev.file.Fd())
+		newDev.file.Close()
+		return nil, &UinputError{Op: op, Err: err}
+	}
+
+	for ev, codes := range config.capabilities {
 		if err := ioctlUISETEVBIT(newDev.file.Fd(), uintptr(ev)); err != nil {
-			DestroyDevice(newDev)
-			return nil, fmt.Errorf("failed to set ev bit: %d - %w", ev, err)
+			return fail(fmt.Sprintf("set ev bit %d", ev), err)
 		}
 
 		if err := setEventCodes(newDev, ev, codes); err != nil {
-			DestroyDevice(newDev)
-			return nil, fmt.Errorf("failed to set ev code: %w", err)
+			return fail(fmt.Sprintf("set codes of ev type %d", ev), err)
 		}
 	}
 
-	if _, err = createInputDevice(newDev.file, UinputUserDevice{
-		Name: toUinputName([]byte(name)),
-		ID:   id,
-	}); err != nil {
-		DestroyDevice(newDev)
-		return nil, fmt.Errorf("failed to create device: %w", err)
+	for _, prop := range config.properties {
+		if err := ioctlUISETPROPBIT(newDev.file.Fd(), uintptr(prop)); err != nil {
+			return fail(fmt.Sprintf("set prop bit %d", prop), err)
+		}
+	}
+
+	if config.phys != "" {
+		if err := ioctlUISETPHYS(newDev.file.Fd(), config.phys); err != nil {
+			return fail("set phys", err)
+		}
+	}
+
+	if version, verr := ioctlUIGETVERSION(newDev.file.Fd()); verr == nil && version >= uinputVersionSetup {
+		err = setupDevice(newDev.file, config)
+	} else {
+		_, err = createInputDevice(newDev.file, legacyUserDevice(config))
 	}
 
-	return newDev, nil
+	if err != nil {
+		return fail("create device", err)
+	}
+
+	if config.repeat != nil {
+		events := []InputEvent{
+			{Type: EV_REP, Code: REP_DELAY, Value: int32(config.repeat[0])},
+			{Type: EV_REP, Code: REP_PERIOD, Value: int32(config.repeat[1])},
+			{Type: EV_SYN, Code: SYN_REPORT},
+		}
+
+		for i := range events {
+			if err := newDev.WriteOne(&events[i]); err != nil {
+				return fail("set repeat settings", err)
+			}
+		}
+	}
+
+	return &VirtualDevice{dev: newDev}, nil
 }
 
 // CloneDevice creates a new device from an existing one
 // all capabilites will be coppied over to the new virtual device
 // If set up fails the device will be removed from the system,
 // once set up it can be removed by calling dev.Close
-func CloneDevice(name string, dev *InputDevice) (*InputDevice, error) {
-	deviceFile, err := os.OpenFile("/dev/uinput", syscall.O_WRONLY|syscall.O_NONBLOCK, 0660)
-	if err != nil {
-		return nil, err
-	}
+func CloneDevice(name string, dev *InputDevice) (*VirtualDevice, error) {
+	return CloneDeviceWithOptions(dev, CloneOptions{Name: name})
+}
+
+// CloneOptions controls how CloneDeviceWithOptions reproduces a device.
+type CloneOptions struct {
+	// Name is the name of the clone. The source device's name is used if empty.
+	Name string
+	// ID is the input ID of the clone. The source device's ID is used if nil.
+	ID *InputID
+	// Phys is the physical location of the clone. The source device's location
+	// is used if empty.
+	Phys string
+	// AddCapabilities are announced in addition to the source device's capabilities.
+	AddCapabilities map[EvType][]EvCode
+	// RemoveCapabilities are not announced even if the source device supports
+	// them. An entry without codes removes the whole event type.
+	RemoveCapabilities map[EvType][]EvCode
+	// AbsInfos override the source device's axis information.
+	AbsInfos map[EvCode]AbsInfo
+}
+
+// CloneDeviceWithOptions creates a new device that reproduces an existing one
+// as closely as uinput allows: capabilities, axis information including the
+// resolution, properties, autorepeat settings, the physical location and the
+// number of force-feedback effects. The unique identifier cannot be set.
+//
+// Force-feedback requests to a clone with EV_FF capabilities must be handled
+// by the caller. Remove EV_FF if the clone should not support force feedback.
+func CloneDeviceWithOptions(dev *InputDevice, options CloneOptions) (*VirtualDevice, error) {
+	config := uinputConfig{
+		name:         options.Name,
+		phys:         options.Phys,
+		capabilities: make(map[EvType][]EvCode),
+		properties:   dev.Properties(),
 
-	newDev := &InputDevice{
-		file:          deviceFile,
 		driverVersion: dev.driverVersion,
 	}
 
+	if config.name == "" {
+		name, err := dev.Name()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get original device name: %w", err)
+		}
+		config.name = name
+	}
+
+	if options.ID != nil {
+		config.id = *options.ID
+	} else {
+		id, err := dev.InputID()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get original device id: %w", err)
+		}
+		config.id = id
+	}
+
+	if config.phys == "" {
+		// not all devices have a physical location
+		config.phys, _ = dev.PhysicalLocation()
+	}
+
 	for _, ev := range dev.CapableTypes() {
-		if err := ioctlUISETEVBIT(newDev.file.Fd(), uintptr(ev)); err != nil {
-			DestroyDevice(newDev)
-			return nil, fmt.Errorf("failed to set ev bit: %d - %w", ev, err)
+		config.capabilities[ev] = dev.CapableEvents(ev)
+	}
+
+	cloneCapabilities(config.capabilities, options)
+
+	if _, ok := config.capabilities[EV_ABS]; ok {
+		absInfos, err := dev.AbsInfos()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get original device abs infos: %w", err)
 		}
 
-		eventCodes := dev.CapableEvents(ev)
-		if err := setEventCodes(newDev, ev, eventCodes); err != nil {
-			DestroyDevice(newDev)
-			return nil, fmt.Errorf("failed to set ev code: %w", err)
+		for code, info := range options.AbsInfos {
+			absInfos[code] = info
 		}
+
+		// removed axes must not be set up again
+		config.absInfos = keepAbsInfos(absInfos, config.capabilities[EV_ABS])
 	}
 
-	id, err := dev.InputID()
-	if err != nil {
-		DestroyDevice(newDev)
-		return nil, fmt.Errorf("failed to get original device id: %w", err)
+	if _, ok := config.capabilities[EV_FF]; ok {
+		effects, err := dev.MaxEffects()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get original device effect count: %w", err)
+		}
+		config.effectsMax = uint32(effects)
 	}
 
-	if _, err = createInputDevice(newDev.file, UinputUserDevice{
-		Name: toUinputName([]byte(name)),
-		ID:   id,
-	}); err != nil {
-		return nil, fmt.Errorf("failed to create device: %w", err)
+	if _, ok := config.capabilities[EV_REP]; ok {
+		if rep, err := ioctlEVIOCGREP(dev.file.Fd()); err == nil {
+			config.repeat = &rep
+		}
 	}
 
-	return newDev, nil
+	return createDevice(config)
 }
 
-// Destroy destroys an input device, removing it from the system
-// This is designed to be called on self created virtual devices and may fail if called
-// on real devices attached to the system
-func DestroyDevice(dev *InputDevice) error {
-	return ioctlUIDEVDESTROY(dev.file.Fd())
+// cloneCapabilities applies the added and removed capabilities of options
+// to the capabilities of a source device.
+func cloneCapabilities(capabilities map[EvType][]EvCode, options CloneOptions) {
+	for ev, codes := range options.AddCapabilities {
+		capabilities[ev] = append(capabilities[ev], codes...)
+	}
+
+	removeCapabilities(capabilities, options.RemoveCapabilities)
+}
+
+// removeCapabilities removes the codes in remove from capabilities. An entry
+// without codes removes the whole event type.
+func removeCapabilities(capabilities, remove map[EvType][]EvCode) {
+	for ev, codes := range remove {
+		if len(codes) == 0 {
+			delete(capabilities, ev)
+			continue
+		}
+
+		removed := make(map[EvCode]bool)
+		for _, code := range codes {
+			removed[code] = true
+		}
+
+		var kept []EvCode
+		for _, code := range capabilities[ev] {
+			if !removed[code] {
+				kept = append(kept, code)
+			}
+		}
+		capabilities[ev] = kept
+	}
+}
+
+// keepAbsInfos returns the entries of absInfos for the given codes.
+func keepAbsInfos(absInfos map[EvCode]AbsInfo, codes []EvCode) map[EvCode]AbsInfo {
+	kept := make(map[EvCode]AbsInfo)
+
+	for _, code := range codes {
+		if info, ok := absInfos[code]; ok {
+			kept[code] = info
+		}
+	}
+
+	return kept
 }
 
 func setEventCodes(dev *InputDevice, ev EvType, codes []EvCode) error {
@@ -138,21 +383,88 @@ func toUinputName(name []byte) (uinputName [uinputMaxNameSize]byte) {
 	return fixedSizeName
 }
 
+// absSetups returns the UI_ABS_SETUP requests for the axes of config,
+// ordered by code.
+func absSetups(config uinputConfig) []uinputAbsSetup {
+	codes := make([]EvCode, 0, len(config.absInfos))
+	for code := range config.absInfos {
+		codes = append(codes, code)
+	}
+
+	var setups []uinputAbsSetup
+	for _, code := range sortCodes(codes) {
+		setups = append(setups, uinputAbsSetup{
+			Code:    uint16(code),
+			AbsInfo: config.absInfos[code],
+		})
+	}
+
+	return setups
+}
+
+// setupDevice creates the device using UI_ABS_SETUP and UI_DEV_SETUP.
+func setupDevice(file *os.File, config uinputConfig) error {
+	for _, setup := range absSetups(config) {
+		setup := setup
+		if err := ioctlUIABSSETUP(file.Fd(), &setup); err != nil {
+			return fmt.Errorf("failed to set up abs axis %d: %w", setup.Code, err)
+		}
+	}
+
+	setup := uinputSetup{
+		ID:         config.id,
+		Name:       toUinputName([]byte(config.name)),
+		EffectsMax: config.effectsMax,
+	}
+
+	if err := ioctlUIDEVSETUP(file.Fd(), &setup); err != nil {
+		return fmt.Errorf("failed to set up device: %w", err)
+	}
+
+	if err := ioctlUIDEVCREATE(file.Fd()); err != nil {
+		return fmt.Errorf("failed to create device: %w", err)
+	}
+
+	return nil
+}
+
+// legacyUserDevice returns the uinput_user_dev struct written to uinput on
+// kernels without UI_DEV_SETUP. It cannot carry the resolution of abs axes.
+func legacyUserDevice(config uinputConfig) UinputUserDevice {
+	uidev := UinputUserDevice{
+		Name:       toUinputName([]byte(config.name)),
+		ID:         config.id,
+		EffectsMax: config.effectsMax,
+	}
+
+	for code, info := range config.absInfos {
+		if code >= absSize {
+			continue
+		}
+
+		uidev.Absmin[code] = info.Minimum
+		uidev.Absmax[code] = info.Maximum
+		uidev.Absfuzz[code] = info.Fuzz
+		uidev.Absflat[code] = info.Flat
+	}
+
+	return uidev
+}
+
+// createInputDevice creates the device by writing the uinput_user_dev struct.
+// On failure, file is left open so the caller can destroy the device first.
 func createInputDevice(file *os.File, dev UinputUserDevice) (fd *os.File, err error) {
 	buf := new(bytes.Buffer)
 
 	if err = binary.Write(buf, binary.LittleEndian, dev); err != nil {
-		file.Close()
 		return nil, fmt.Errorf("failed to write user device buffer: %w", err)
 	}
 
 	if _, err = file.Write(buf.Bytes()); err != nil {
-		file.Close()
 		return nil, fmt.Errorf("failed to write uidev struct to device file: %w", err)
 	}
 
 	if err = ioctlUIDEVCREATE(file.Fd()); err != nil {
-		file.Close()
 		return nil, fmt.Errorf("failed to create device: %w", err)
 	}
 
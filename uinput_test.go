@@ -0,0 +1,116 @@
+package evdev
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCloneRemovedAbsNotSetUp(t *testing.T) {
+	capabilities := map[EvType][]EvCode{
+		EV_KEY: {BTN_TOUCH, BTN_TOOL_PEN},
+		EV_ABS: {ABS_X, ABS_Y, ABS_PRESSURE},
+	}
+
+	absInfos := map[EvCode]AbsInfo{
+		ABS_X:        {Maximum: 1919, Resolution: 10},
+		ABS_Y:        {Maximum: 1079, Resolution: 10},
+		ABS_PRESSURE: {Maximum: 255},
+	}
+
+	removeCapabilities(capabilities, map[EvType][]EvCode{
+		EV_KEY: {BTN_TOOL_PEN},
+		EV_ABS: {ABS_PRESSURE},
+	})
+
+	if want := []EvCode{ABS_X, ABS_Y}; !reflect.DeepEqual(capabilities[EV_ABS], want) {
+		t.Errorf("EV_ABS capabilities = %v, want %v", capabilities[EV_ABS], want)
+	}
+
+	config := uinputConfig{
+		capabilities: capabilities,
+		absInfos:     keepAbsInfos(absInfos, capabilities[EV_ABS]),
+	}
+
+	want := []uinputAbsSetup{
+		{Code: uint16(ABS_X), AbsInfo: absInfos[ABS_X]},
+		{Code: uint16(ABS_Y), AbsInfo: absInfos[ABS_Y]},
+	}
+
+	if got := absSetups(config); !reflect.DeepEqual(got, want) {
+		t.Errorf("absSetups() = %v, want %v", got, want)
+	}
+}
+
+func Test_removeCapabilities(t *testing.T) {
+	capabilities := map[EvType][]EvCode{
+		EV_KEY: {KEY_A, KEY_B},
+		EV_FF:  {FF_RUMBLE},
+	}
+
+	removeCapabilities(capabilities, map[EvType][]EvCode{
+		EV_KEY: {KEY_A},
+		EV_FF:  nil,
+	})
+
+	want := map[EvType][]EvCode{EV_KEY: {KEY_B}}
+	if !reflect.DeepEqual(capabilities, want) {
+		t.Errorf("capabilities = %v, want %v", capabilities, want)
+	}
+}
+
+func Test_createConfig(t *testing.T) {
+	id := InputID{BusType: BUS_VIRTUAL}
+	rumble := map[EvType][]EvCode{
+		EV_KEY: {BTN_SOUTH},
+		EV_FF:  {FF_RUMBLE},
+	}
+
+	tests := []struct {
+		name         string
+		capabilities map[EvType][]EvCode
+		options      CreateOptions
+		wantAbs      []EvCode
+		wantEffects  uint32
+	}{
+		{
+			name:         "no force feedback",
+			capabilities: map[EvType][]EvCode{EV_KEY: {BTN_SOUTH}},
+			wantEffects:  0,
+		},
+		{
+			name:         "default effect count",
+			capabilities: rumble,
+			wantEffects:  defaultEffectsMax,
+		},
+		{
+			name:         "effect count",
+			capabilities: rumble,
+			options:      CreateOptions{EffectsMax: 4},
+			wantEffects:  4,
+		},
+		{
+			name:         "axes from abs infos",
+			capabilities: map[EvType][]EvCode{EV_ABS: {ABS_X}},
+			options: CreateOptions{AbsInfos: map[EvCode]AbsInfo{
+				ABS_X:  {Maximum: 10},
+				ABS_RZ: {Maximum: 255},
+				ABS_Z:  {Maximum: 255},
+			}},
+			wantAbs: []EvCode{ABS_X, ABS_Z, ABS_RZ},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := createConfig("test", id, tt.capabilities, tt.options)
+
+			if config.effectsMax != tt.wantEffects {
+				t.Errorf("effectsMax = %d, want %d", config.effectsMax, tt.wantEffects)
+			}
+
+			if !reflect.DeepEqual(config.capabilities[EV_ABS], tt.wantAbs) {
+				t.Errorf("EV_ABS = %v, want %v", config.capabilities[EV_ABS], tt.wantAbs)
+			}
+		})
+	}
+}
@@ -0,0 +1,425 @@
+package evdev
+
+import "fmt"
+
+// virtualDevice is the common part of the virtual device builders.
+type virtualDevice struct {
+	dev *VirtualDevice
+}
+
+// Device returns the underlying VirtualDevice.
+func (v *virtualDevice) Device() *VirtualDevice {
+	return v.dev
+}
+
+// Close destroys the virtual device.
+func (v *virtualDevice) Close() error {
+	return v.dev.Close()
+}
+
+// emit writes the events followed by a SYN_REPORT.
+func (v *virtualDevice) emit(events ...InputEvent) error {
+	return v.dev.WriteFrame(events...)
+}
+
+func (v *virtualDevice) button(code EvCode, value int32) error {
+	return v.emit(InputEvent{Type: EV_KEY, Code: code, Value: value})
+}
+
+func (v *virtualDevice) click(code EvCode) error {
+	if err := v.button(code, 1); err != nil {
+		return err
+	}
+
+	return v.button(code, 0)
+}
+
+func absEvent(code EvCode, value int32) InputEvent {
+	return InputEvent{Type: EV_ABS, Code: code, Value: value}
+}
+
+func relEvent(code EvCode, value int32) InputEvent {
+	return InputEvent{Type: EV_REL, Code: code, Value: value}
+}
+
+// VirtualMouse is a virtual relative pointing device with five buttons and
+// vertical and horizontal scroll wheels.
+type VirtualMouse struct {
+	virtualDevice
+}
+
+// NewVirtualMouse creates a new virtual mouse.
+func NewVirtualMouse(name string) (*VirtualMouse, error) {
+	dev, err := createDevice(uinputConfig{
+		name: name,
+		id:   InputID{BusType: BUS_VIRTUAL},
+		capabilities: map[EvType][]EvCode{
+			EV_KEY: {BTN_LEFT, BTN_RIGHT, BTN_MIDDLE, BTN_SIDE, BTN_EXTRA},
+			EV_REL: {REL_X, REL_Y, REL_WHEEL, REL_HWHEEL},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &VirtualMouse{virtualDevice{dev: dev}}, nil
+}
+
+// Move moves the pointer relative to its current position.
+func (m *VirtualMouse) Move(dx, dy int32) error {
+	var events []InputEvent
+	if dx != 0 {
+		events = append(events, relEvent(REL_X, dx))
+	}
+	if dy != 0 {
+		events = append(events, relEvent(REL_Y, dy))
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	return m.emit(events...)
+}
+
+// Scroll turns the scroll wheels by the given number of detents.
+// Positive values scroll up and right, respectively.
+func (m *VirtualMouse) Scroll(vertical, horizontal int32) error {
+	var events []InputEvent
+	if vertical != 0 {
+		events = append(events, relEvent(REL_WHEEL, vertical))
+	}
+	if horizontal != 0 {
+		events = append(events, relEvent(REL_HWHEEL, horizontal))
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	return m.emit(events...)
+}
+
+// PressButton presses a mouse button such as BTN_LEFT.
+func (m *VirtualMouse) PressButton(button EvCode) error {
+	return m.button(button, 1)
+}
+
+// ReleaseButton releases a mouse button.
+func (m *VirtualMouse) ReleaseButton(button EvCode) error {
+	return m.button(button, 0)
+}
+
+// Click presses and releases a mouse button.
+func (m *VirtualMouse) Click(button EvCode) error {
+	return m.click(button)
+}
+
+// VirtualTablet is a virtual absolute pointing device with three buttons and
+// a scroll wheel, similar to the tablet devices of virtual machines.
+type VirtualTablet struct {
+	virtualDevice
+}
+
+// NewVirtualTablet creates a new virtual tablet with a coordinate range of
+// 0 to width-1 and 0 to height-1.
+func NewVirtualTablet(name string, width, height int32) (*VirtualTablet, error) {
+	dev, err := createDevice(uinputConfig{
+		name: name,
+		id:   InputID{BusType: BUS_VIRTUAL},
+		capabilities: map[EvType][]EvCode{
+			EV_KEY: {BTN_LEFT, BTN_RIGHT, BTN_MIDDLE},
+			EV_REL: {REL_WHEEL},
+			EV_ABS: {ABS_X, ABS_Y},
+		},
+		absInfos: map[EvCode]AbsInfo{
+			ABS_X: {Maximum: width - 1},
+			ABS_Y: {Maximum: height - 1},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &VirtualTablet{virtualDevice{dev: dev}}, nil
+}
+
+// MoveTo moves the pointer to the given position.
+func (t *VirtualTablet) MoveTo(x, y int32) error {
+	return t.emit(absEvent(ABS_X, x), absEvent(ABS_Y, y))
+}
+
+// Scroll turns the scroll wheel by the given number of detents.
+// Positive values scroll up.
+func (t *VirtualTablet) Scroll(vertical int32) error {
+	return t.emit(relEvent(REL_WHEEL, vertical))
+}
+
+// PressButton presses a button such as BTN_LEFT.
+func (t *VirtualTablet) PressButton(button EvCode) error {
+	return t.button(button, 1)
+}
+
+// ReleaseButton releases a button.
+func (t *VirtualTablet) ReleaseButton(button EvCode) error {
+	return t.button(button, 0)
+}
+
+// Click presses and releases a button.
+func (t *VirtualTablet) Click(button EvCode) error {
+	return t.click(button)
+}
+
+type virtualContact struct {
+	active bool
+	x, y   int32
+}
+
+// VirtualTouchscreen is a virtual multi-touch screen following the
+// type B protocol.
+type VirtualTouchscreen struct {
+	virtualDevice
+
+	contacts []virtualContact
+	slot     int
+	nextID   int32
+}
+
+// NewVirtualTouchscreen creates a new virtual touchscreen with the given
+// number of slots and a coordinate range of 0 to width-1 and 0 to height-1.
+func NewVirtualTouchscreen(name string, width, height int32, slots int) (*VirtualTouchscreen, error) {
+	if slots < 1 {
+		return nil, fmt.Errorf("invalid number of slots: %d", slots)
+	}
+
+	dev, err := createDevice(uinputConfig{
+		name: name,
+		id:   InputID{BusType: BUS_VIRTUAL},
+		capabilities: map[EvType][]EvCode{
+			EV_KEY: {BTN_TOUCH},
+			EV_ABS: {ABS_X, ABS_Y, ABS_MT_SLOT, ABS_MT_TRACKING_ID, ABS_MT_POSITION_X, ABS_MT_POSITION_Y},
+		},
+		absInfos: map[EvCode]AbsInfo{
+			ABS_X:              {Maximum: width - 1},
+			ABS_Y:              {Maximum: height - 1},
+			ABS_MT_SLOT:        {Maximum: int32(slots - 1)},
+			ABS_MT_TRACKING_ID: {Maximum: 0xffff},
+			ABS_MT_POSITION_X:  {Maximum: width - 1},
+			ABS_MT_POSITION_Y:  {Maximum: height - 1},
+		},
+		properties: []EvProp{INPUT_PROP_DIRECT},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &VirtualTouchscreen{
+		virtualDevice: virtualDevice{dev: dev},
+		contacts:      make([]virtualContact, slots),
+	}, nil
+}
+
+// primary returns the slot of the contact reported in ABS_X and ABS_Y,
+// ignoring the given slot, or -1 if there is none.
+func (t *VirtualTouchscreen) primary(except int) int {
+	for i, c := range t.contacts {
+		if c.active && i != except {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (t *VirtualTouchscreen) selectSlot(slot int) []InputEvent {
+	if slot == t.slot {
+		return nil
+	}
+
+	return []InputEvent{absEvent(ABS_MT_SLOT, int32(slot))}
+}
+
+// touchEvents returns the events for Touch without changing the state of t,
+// which is updated by touched once they are written.
+func (t *VirtualTouchscreen) touchEvents(slot int, x, y int32) ([]InputEvent, error) {
+	if slot < 0 || slot >= len(t.contacts) {
+		return nil, fmt.Errorf("invalid slot %d", slot)
+	}
+
+	events := t.selectSlot(slot)
+	p := t.primary(slot)
+
+	if !t.contacts[slot].active {
+		events = append(events, absEvent(ABS_MT_TRACKING_ID, t.nextID))
+	}
+
+	events = append(events, absEvent(ABS_MT_POSITION_X, x), absEvent(ABS_MT_POSITION_Y, y))
+
+	if p < 0 {
+		events = append(events, InputEvent{Type: EV_KEY, Code: BTN_TOUCH, Value: 1})
+	}
+
+	if p < 0 || slot < p {
+		events = append(events, absEvent(ABS_X, x), absEvent(ABS_Y, y))
+	}
+
+	return events, nil
+}
+
+func (t *VirtualTouchscreen) touched(slot int, x, y int32) {
+	t.slot = slot
+	c := &t.contacts[slot]
+
+	if !c.active {
+		c.active = true
+		t.nextID = (t.nextID + 1) & 0xffff
+	}
+
+	c.x, c.y = x, y
+}
+
+// liftEvents returns the events for Lift without changing the state of t,
+// which is updated by lifted once they are written.
+func (t *VirtualTouchscreen) liftEvents(slot int) ([]InputEvent, error) {
+	if slot < 0 || slot >= len(t.contacts) {
+		return nil, fmt.Errorf("invalid slot %d", slot)
+	}
+
+	if !t.contacts[slot].active {
+		return nil, nil
+	}
+
+	wasPrimary := t.primary(-1) == slot
+
+	events := t.selectSlot(slot)
+	events = append(events, absEvent(ABS_MT_TRACKING_ID, -1))
+
+	switch p := t.primary(slot); {
+	case p < 0:
+		events = append(events, InputEvent{Type: EV_KEY, Code: BTN_TOUCH, Value: 0})
+	case wasPrimary:
+		events = append(events, absEvent(ABS_X, t.contacts[p].x), absEvent(ABS_Y, t.contacts[p].y))
+	}
+
+	return events, nil
+}
+
+func (t *VirtualTouchscreen) lifted(slot int) {
+	t.slot = slot
+	t.contacts[slot].active = false
+}
+
+// Touch puts a contact down at the given position or moves it if the slot
+// is already in use.
+func (t *VirtualTouchscreen) Touch(slot int, x, y int32) error {
+	events, err := t.touchEvents(slot, x, y)
+	if err != nil {
+		return err
+	}
+
+	if err := t.emit(events...); err != nil {
+		return err
+	}
+
+	t.touched(slot, x, y)
+
+	return nil
+}
+
+// Lift lifts the contact in the given slot. It is a no-op if the slot is not
+// in use.
+func (t *VirtualTouchscreen) Lift(slot int) error {
+	events, err := t.liftEvents(slot)
+	if err != nil || len(events) == 0 {
+		return err
+	}
+
+	if err := t.emit(events...); err != nil {
+		return err
+	}
+
+	t.lifted(slot)
+
+	return nil
+}
+
+// Ranges of the axes of a VirtualGamepad.
+const (
+	GamepadStickMin   = -32768
+	GamepadStickMax   = 32767
+	GamepadTriggerMax = 255
+)
+
+// VirtualGamepad is a virtual gamepad with the standard face, shoulder and
+// menu buttons, two analog sticks, two analog triggers and a d-pad reported
+// as a hat switch.
+type VirtualGamepad struct {
+	virtualDevice
+}
+
+// NewVirtualGamepad creates a new virtual gamepad.
+func NewVirtualGamepad(name string) (*VirtualGamepad, error) {
+	stick := AbsInfo{Minimum: GamepadStickMin, Maximum: GamepadStickMax, Fuzz: 16, Flat: 128}
+	trigger := AbsInfo{Maximum: GamepadTriggerMax}
+	hat := AbsInfo{Minimum: -1, Maximum: 1}
+
+	dev, err := createDevice(uinputConfig{
+		name: name,
+		id:   InputID{BusType: BUS_VIRTUAL},
+		capabilities: map[EvType][]EvCode{
+			EV_KEY: {
+				BTN_SOUTH, BTN_EAST, BTN_NORTH, BTN_WEST, BTN_TL, BTN_TR,
+				BTN_SELECT, BTN_START, BTN_MODE, BTN_THUMBL, BTN_THUMBR,
+			},
+			EV_ABS: {ABS_X, ABS_Y, ABS_RX, ABS_RY, ABS_Z, ABS_RZ, ABS_HAT0X, ABS_HAT0Y},
+		},
+		absInfos: map[EvCode]AbsInfo{
+			ABS_X:     stick,
+			ABS_Y:     stick,
+			ABS_RX:    stick,
+			ABS_RY:    stick,
+			ABS_Z:     trigger,
+			ABS_RZ:    trigger,
+			ABS_HAT0X: hat,
+			ABS_HAT0Y: hat,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &VirtualGamepad{virtualDevice{dev: dev}}, nil
+}
+
+// PressButton presses a button such as BTN_SOUTH.
+func (g *VirtualGamepad) PressButton(button EvCode) error {
+	return g.button(button, 1)
+}
+
+// ReleaseButton releases a button.
+func (g *VirtualGamepad) ReleaseButton(button EvCode) error {
+	return g.button(button, 0)
+}
+
+// LeftStick moves the left stick to the given position between
+// GamepadStickMin and GamepadStickMax.
+func (g *VirtualGamepad) LeftStick(x, y int32) error {
+	return g.emit(absEvent(ABS_X, x), absEvent(ABS_Y, y))
+}
+
+// RightStick moves the right stick to the given position between
+// GamepadStickMin and GamepadStickMax.
+func (g *VirtualGamepad) RightStick(x, y int32) error {
+	return g.emit(absEvent(ABS_RX, x), absEvent(ABS_RY, y))
+}
+
+// Triggers sets the analog triggers to values between 0 and GamepadTriggerMax.
+func (g *VirtualGamepad) Triggers(left, right int32) error {
+	return g.emit(absEvent(ABS_Z, left), absEvent(ABS_RZ, right))
+}
+
+// DPad sets the d-pad direction. x and y are -1, 0 or 1; negative values
+// point left and up.
+func (g *VirtualGamepad) DPad(x, y int32) error {
+	return g.emit(absEvent(ABS_HAT0X, x), absEvent(ABS_HAT0Y, y))
+}
@@ -0,0 +1,134 @@
+package evdev
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVirtualTouchscreenEvents(t *testing.T) {
+	ts := &VirtualTouchscreen{contacts: make([]virtualContact, 2)}
+
+	// touch and lift update the state as if the events had been written
+	touch := func(slot int, x, y int32) func() ([]InputEvent, error) {
+		return func() ([]InputEvent, error) {
+			events, err := ts.touchEvents(slot, x, y)
+			if err == nil {
+				ts.touched(slot, x, y)
+			}
+			return events, err
+		}
+	}
+	lift := func(slot int) func() ([]InputEvent, error) {
+		return func() ([]InputEvent, error) {
+			events, err := ts.liftEvents(slot)
+			if err == nil && len(events) > 0 {
+				ts.lifted(slot)
+			}
+			return events, err
+		}
+	}
+
+	btnTouch := func(value int32) InputEvent {
+		return InputEvent{Type: EV_KEY, Code: BTN_TOUCH, Value: value}
+	}
+
+	steps := []struct {
+		name    string
+		action  func() ([]InputEvent, error)
+		want    []InputEvent
+		wantErr bool
+	}{
+		{
+			name:   "first contact",
+			action: touch(0, 10, 20),
+			want: []InputEvent{
+				absEvent(ABS_MT_TRACKING_ID, 0),
+				absEvent(ABS_MT_POSITION_X, 10), absEvent(ABS_MT_POSITION_Y, 20),
+				btnTouch(1),
+				absEvent(ABS_X, 10), absEvent(ABS_Y, 20),
+			},
+		},
+		{
+			name:   "second contact",
+			action: touch(1, 30, 40),
+			want: []InputEvent{
+				absEvent(ABS_MT_SLOT, 1),
+				absEvent(ABS_MT_TRACKING_ID, 1),
+				absEvent(ABS_MT_POSITION_X, 30), absEvent(ABS_MT_POSITION_Y, 40),
+			},
+		},
+		{
+			name:   "move first contact",
+			action: touch(0, 11, 21),
+			want: []InputEvent{
+				absEvent(ABS_MT_SLOT, 0),
+				absEvent(ABS_MT_POSITION_X, 11), absEvent(ABS_MT_POSITION_Y, 21),
+				absEvent(ABS_X, 11), absEvent(ABS_Y, 21),
+			},
+		},
+		{
+			name:   "lift first contact",
+			action: lift(0),
+			want: []InputEvent{
+				absEvent(ABS_MT_TRACKING_ID, -1),
+				absEvent(ABS_X, 30), absEvent(ABS_Y, 40),
+			},
+		},
+		{
+			name:   "lift second contact",
+			action: lift(1),
+			want: []InputEvent{
+				absEvent(ABS_MT_SLOT, 1),
+				absEvent(ABS_MT_TRACKING_ID, -1),
+				btnTouch(0),
+			},
+		},
+		{
+			name:   "lift unused slot",
+			action: lift(1),
+		},
+		{
+			name:    "invalid slot",
+			action:  touch(2, 0, 0),
+			wantErr: true,
+		},
+	}
+	for _, tt := range steps {
+		got, err := tt.action()
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: events = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestVirtualTouchscreenEventsKeepState(t *testing.T) {
+	ts := &VirtualTouchscreen{contacts: make([]virtualContact, 2)}
+
+	want := []InputEvent{
+		absEvent(ABS_MT_SLOT, 1),
+		absEvent(ABS_MT_TRACKING_ID, 0),
+		absEvent(ABS_MT_POSITION_X, 10), absEvent(ABS_MT_POSITION_Y, 20),
+		{Type: EV_KEY, Code: BTN_TOUCH, Value: 1},
+		absEvent(ABS_X, 10), absEvent(ABS_Y, 20),
+	}
+
+	// without touched, e.g. after a failed write, the same events are due again
+	for i := 0; i < 2; i++ {
+		got, err := ts.touchEvents(1, 10, 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("attempt %d: events = %v, want %v", i, got, want)
+		}
+	}
+
+	if got, _ := ts.liftEvents(1); got != nil {
+		t.Errorf("liftEvents() of untouched slot = %v, want nil", got)
+	}
+}
@@ -0,0 +1,320 @@
+package evdev
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// ErrVirtualDeviceClosed is returned by operations on a closed VirtualDevice.
+var ErrVirtualDeviceClosed = errors.New("virtual device is closed")
+
+// UinputError is returned when an operation on /dev/uinput fails. Err is the
+// underlying error, e.g. an os.PathError for which errors.Is(err,
+// fs.ErrPermission) holds if /dev/uinput cannot be opened, or a syscall.Errno.
+type UinputError struct {
+	Op  string
+	Err error
+}
+
+func (e *UinputError) Error() string {
+	return fmt.Sprintf("uinput: cannot %s: %v", e.Op, e.Err)
+}
+
+func (e *UinputError) Unwrap() error {
+	return e.Err
+}
+
+// uinputPollInterval is the interval in which OpenEventNode checks for the
+// event node of a new device.
+const uinputPollInterval = 10 * time.Millisecond
+
+// VirtualDevice is an input device created via uinput, e.g. with CreateDevice
+// or CloneDevice. Events written to it appear on its event node in /dev/input,
+// which can be opened as an InputDevice using OpenEventNode.
+type VirtualDevice struct {
+	// Timestamp makes the write methods set the time of events that have none
+	// to the current time. The kernel stamps the events it delivers to clients
+	// itself, so this only matters to code inspecting the written events.
+	Timestamp bool
+
+	// dev wraps the uinput file descriptor, as reading feedback from it works
+	// just like reading events from an event node.
+	dev    *InputDevice
+	closed bool
+
+	mu      sync.Mutex
+	buf     []byte       // scratch buffer for serialized events
+	pending []InputEvent // events queued by Emit
+}
+
+// inputEventSize is the size of struct input_event.
+const inputEventSize = int(unsafe.Sizeof(InputEvent{}))
+
+// appendInputEvent appends the binary representation of the event to b,
+// using now as its time if it has none. The fields of syscall.Timeval are
+// 32 bits wide on 32-bit architectures.
+func appendInputEvent(b []byte, event *InputEvent, now syscall.Timeval) []byte {
+	tv := event.Time
+	if tv.Sec == 0 && tv.Usec == 0 {
+		tv = now
+	}
+
+	n := len(b)
+	b = append(b, make([]byte, inputEventSize)...)
+	e := b[n:]
+
+	if unsafe.Sizeof(tv.Sec) == 8 {
+		binary.LittleEndian.PutUint64(e[0:], uint64(tv.Sec))
+		binary.LittleEndian.PutUint64(e[8:], uint64(tv.Usec))
+		e = e[16:]
+	} else {
+		binary.LittleEndian.PutUint32(e[0:], uint32(tv.Sec))
+		binary.LittleEndian.PutUint32(e[4:], uint32(tv.Usec))
+		e = e[8:]
+	}
+
+	binary.LittleEndian.PutUint16(e[0:], uint16(event.Type))
+	binary.LittleEndian.PutUint16(e[2:], uint16(event.Code))
+	binary.LittleEndian.PutUint32(e[4:], uint32(event.Value))
+
+	return b
+}
+
+// now returns the time to stamp events with, which is zero if Timestamp is
+// not set.
+func (v *VirtualDevice) now() syscall.Timeval {
+	if !v.Timestamp {
+		return syscall.Timeval{}
+	}
+
+	return syscall.NsecToTimeval(time.Now().UnixNano())
+}
+
+// writeEvents serializes the events, optionally followed by a SYN_REPORT,
+// and writes them with a single write. v.mu must be held.
+func (v *VirtualDevice) writeEvents(events []InputEvent, syn bool) error {
+	now := v.now()
+
+	v.buf = v.buf[:0]
+	for i := range events {
+		v.buf = appendInputEvent(v.buf, &events[i], now)
+	}
+
+	if syn {
+		v.buf = appendInputEvent(v.buf, &InputEvent{Type: EV_SYN, Code: SYN_REPORT}, now)
+	}
+
+	if _, err := v.dev.file.Write(v.buf); err != nil {
+		return &UinputError{Op: "write events", Err: err}
+	}
+
+	return nil
+}
+
+// Close destroys the device, removing it from the system, and releases its
+// file descriptor. A running HandleFeedback must be stopped first.
+func (v *VirtualDevice) Close() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.closed {
+		return ErrVirtualDeviceClosed
+	}
+
+	v.closed = true
+
+	var err error
+	if e := ioctlUIDEVDESTROY(v.dev.file.Fd()); e != nil {
+		err = &UinputError{Op: "destroy device", Err: e}
+	}
+
+	if e := v.dev.Close(); e != nil && err == nil {
+		err = e
+	}
+
+	return err
+}
+
+func (v *VirtualDevice) isClosed() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.closed
+}
+
+// DestroyDevice destroys a virtual device, removing it from the system.
+//
+// Deprecated: Use (*VirtualDevice).Close instead.
+func DestroyDevice(dev *VirtualDevice) error {
+	return dev.Close()
+}
+
+// WriteOne writes one InputEvent to the device. The event only takes effect
+// for clients once a SYN_REPORT is written.
+func (v *VirtualDevice) WriteOne(event *InputEvent) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.closed {
+		return ErrVirtualDeviceClosed
+	}
+
+	return v.writeEvents([]InputEvent{*event}, false)
+}
+
+// WriteEvents writes the events to the device with a single write. Unlike
+// WriteFrame, it does not append a SYN_REPORT.
+func (v *VirtualDevice) WriteEvents(events []InputEvent) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.closed {
+		return ErrVirtualDeviceClosed
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	return v.writeEvents(events, false)
+}
+
+// WriteFrame writes the events followed by a SYN_REPORT to the device with
+// a single write, so clients receive them as one frame.
+func (v *VirtualDevice) WriteFrame(events ...InputEvent) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.closed {
+		return ErrVirtualDeviceClosed
+	}
+
+	return v.writeEvents(events, true)
+}
+
+// Emit queues an event to be written by the next call to Sync.
+func (v *VirtualDevice) Emit(evType EvType, code EvCode, value int32) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.closed {
+		return ErrVirtualDeviceClosed
+	}
+
+	v.pending = append(v.pending, InputEvent{Type: evType, Code: code, Value: value})
+
+	return nil
+}
+
+// Sync writes the events queued by Emit followed by a SYN_REPORT to the
+// device with a single write.
+func (v *VirtualDevice) Sync() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.closed {
+		return ErrVirtualDeviceClosed
+	}
+
+	err := v.writeEvents(v.pending, true)
+	v.pending = v.pending[:0]
+
+	return err
+}
+
+// SysfsPath returns the sysfs directory of the device,
+// e.g. /sys/devices/virtual/input/input42.
+func (v *VirtualDevice) SysfsPath() (string, error) {
+	v.mu.Lock()
+
+	if v.closed {
+		v.mu.Unlock()
+		return "", ErrVirtualDeviceClosed
+	}
+
+	sysname, err := ioctlUIGETSYSNAME(v.dev.file.Fd())
+	v.mu.Unlock()
+
+	if err != nil {
+		return "", &UinputError{Op: "get sysname", Err: err}
+	}
+
+	return filepath.EvalSymlinks(filepath.Join(sysfsInputPath, sysname))
+}
+
+// eventNode returns the path of the event node in /dev/input listed in the
+// sysfs directory of an input device, or an empty string if there is none yet.
+func eventNode(sysfsPath string) (string, error) {
+	entries, err := os.ReadDir(sysfsPath)
+	if err != nil {
+		return "", err
+	}
+
+	for _, entry := range entries {
+		if !isEventNode(entry.Name()) {
+			continue
+		}
+
+		// like deviceInfoFromSysfs, prefer the node name udev is told about
+		env, err := readUeventFile(filepath.Join(sysfsPath, entry.Name(), "uevent"))
+		if devName, ok := env["DEVNAME"]; err == nil && ok {
+			return filepath.Join("/dev", devName), nil
+		}
+
+		return filepath.Join("/dev/input", entry.Name()), nil
+	}
+
+	return "", nil
+}
+
+// OpenEventNode waits until the event node of the device exists and can be
+// opened, and returns it. The event node can be used to read back the
+// injected events.
+func (v *VirtualDevice) OpenEventNode(timeout time.Duration) (*InputDevice, error) {
+	sysfsPath, err := v.SysfsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+
+	for {
+		path, err := eventNode(sysfsPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if path != "" {
+			// udev may not have applied the permissions yet
+			if dev, err := Open(path); err == nil || time.Now().After(deadline) {
+				return dev, err
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timeout waiting for event node in %s", sysfsPath)
+		}
+
+		time.Sleep(uinputPollInterval)
+	}
+}
+
+// EventPath waits until the event node of the device exists and can be
+// opened, and returns its path, e.g. /dev/input/event7.
+func (v *VirtualDevice) EventPath(timeout time.Duration) (string, error) {
+	dev, err := v.OpenEventNode(timeout)
+	if err != nil {
+		return "", err
+	}
+
+	path := dev.Path()
+
+	return path, dev.Close()
+}
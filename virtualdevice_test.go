@@ -0,0 +1,281 @@
+package evdev
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// pipeVirtualDevice returns a VirtualDevice writing to a pipe instead of
+// /dev/uinput, and the read end of the pipe.
+func pipeVirtualDevice(t *testing.T) (*VirtualDevice, *os.File) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		r.Close()
+		w.Close()
+	})
+
+	return &VirtualDevice{dev: &InputDevice{file: w}}, r
+}
+
+// readEvents reads n events written by binary.Write from r.
+func readEvents(t *testing.T, r io.Reader, n int) []InputEvent {
+	events := make([]InputEvent, n)
+
+	buf := make([]byte, binary.Size(events))
+	if _, err := io.ReadFull(r, buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := binary.Read(bytes.NewReader(buf), binary.LittleEndian, events); err != nil {
+		t.Fatal(err)
+	}
+
+	return events
+}
+
+func TestAppendInputEvent(t *testing.T) {
+	events := []InputEvent{
+		{Time: syscall.Timeval{Sec: 1700000000, Usec: 123456}, Type: EV_ABS, Code: ABS_X, Value: -4096},
+		{Type: EV_KEY, Code: BTN_TOUCH, Value: 1},
+		{Time: syscall.Timeval{Sec: -1, Usec: 999999}, Type: EV_SYN, Code: SYN_REPORT},
+	}
+
+	for _, event := range events {
+		want := new(bytes.Buffer)
+		if err := binary.Write(want, binary.LittleEndian, &event); err != nil {
+			t.Fatal(err)
+		}
+
+		got := appendInputEvent(nil, &event, syscall.Timeval{})
+		if !bytes.Equal(got, want.Bytes()) {
+			t.Errorf("%v: got %x, want %x", event, got, want.Bytes())
+		}
+	}
+}
+
+func TestVirtualDeviceWriteFrame(t *testing.T) {
+	v, r := pipeVirtualDevice(t)
+
+	events := []InputEvent{
+		relEvent(REL_X, 5),
+		relEvent(REL_Y, -3),
+	}
+
+	if err := v.WriteFrame(events...); err != nil {
+		t.Fatal(err)
+	}
+
+	want := append(events, InputEvent{Type: EV_SYN, Code: SYN_REPORT})
+	if got := readEvents(t, r, len(want)); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestVirtualDeviceEmitSync(t *testing.T) {
+	v, r := pipeVirtualDevice(t)
+
+	for i := 0; i < 2; i++ {
+		if err := v.Emit(EV_ABS, ABS_X, int32(i)); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := v.Emit(EV_ABS, ABS_Y, int32(-i)); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := v.Sync(); err != nil {
+			t.Fatal(err)
+		}
+
+		want := []InputEvent{
+			absEvent(ABS_X, int32(i)),
+			absEvent(ABS_Y, int32(-i)),
+			{Type: EV_SYN, Code: SYN_REPORT},
+		}
+
+		if got := readEvents(t, r, len(want)); !reflect.DeepEqual(got, want) {
+			t.Errorf("frame %d: got %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestVirtualDeviceTimestamp(t *testing.T) {
+	v, r := pipeVirtualDevice(t)
+	v.Timestamp = true
+
+	fixed := syscall.Timeval{Sec: 42, Usec: 7}
+	before := time.Now().Add(-time.Second).Unix()
+
+	if err := v.WriteEvents([]InputEvent{
+		{Time: fixed, Type: EV_REL, Code: REL_X, Value: 1},
+		{Type: EV_REL, Code: REL_Y, Value: 1},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := readEvents(t, r, 2)
+
+	if got[0].Time != fixed {
+		t.Errorf("got time %v, want %v", got[0].Time, fixed)
+	}
+
+	if sec := int64(got[1].Time.Sec); sec < before {
+		t.Errorf("got time %v, want current time", got[1].Time)
+	}
+}
+
+func TestVirtualDeviceWriteEventsAllocs(t *testing.T) {
+	v, r := pipeVirtualDevice(t)
+	v.Timestamp = true
+
+	events := []InputEvent{
+		absEvent(ABS_X, 100),
+		absEvent(ABS_Y, 200),
+		{Type: EV_SYN, Code: SYN_REPORT},
+	}
+
+	go io.Copy(io.Discard, r)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		if err := v.WriteEvents(events); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if allocs != 0 {
+		t.Errorf("got %v allocations per WriteEvents, want 0", allocs)
+	}
+}
+
+func TestVirtualDeviceClosed(t *testing.T) {
+	v := &VirtualDevice{closed: true}
+
+	if err := v.Close(); err != ErrVirtualDeviceClosed {
+		t.Errorf("Close: got %v, want %v", err, ErrVirtualDeviceClosed)
+	}
+
+	if err := v.WriteFrame(); err != ErrVirtualDeviceClosed {
+		t.Errorf("WriteFrame: got %v, want %v", err, ErrVirtualDeviceClosed)
+	}
+
+	if _, err := v.SysfsPath(); err != ErrVirtualDeviceClosed {
+		t.Errorf("SysfsPath: got %v, want %v", err, ErrVirtualDeviceClosed)
+	}
+
+	if err := v.HandleFeedback(context.Background(), FeedbackHandler{}); err != ErrVirtualDeviceClosed {
+		t.Errorf("HandleFeedback: got %v, want %v", err, ErrVirtualDeviceClosed)
+	}
+}
+
+func TestVirtualDeviceCloseConcurrent(t *testing.T) {
+	v, _ := pipeVirtualDevice(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 100; i++ {
+			_, _ = v.SysfsPath()
+			_ = v.HandleFeedback(ctx, FeedbackHandler{})
+		}
+	}()
+
+	if err := v.Close(); err == nil {
+		t.Error("Close of a pipe succeeded, want destroy error")
+	}
+
+	<-done
+}
+
+func TestUinputError(t *testing.T) {
+	err := error(&UinputError{Op: "create device", Err: syscall.EINVAL})
+
+	if got, want := err.Error(), "uinput: cannot create device: invalid argument"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if !errors.Is(err, syscall.EINVAL) {
+		t.Errorf("errors.Is(%v, EINVAL) = false", err)
+	}
+}
+
+func Test_eventNode(t *testing.T) {
+	write := func(path, content string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name:  "not yet created",
+			files: map[string]string{"name": "virtual\n"},
+			want:  "",
+		},
+		{
+			name: "devname",
+			files: map[string]string{
+				"name":            "virtual\n",
+				"mouse2/uevent":   "MAJOR=13\nMINOR=34\nDEVNAME=input/mouse2\n",
+				"event7/uevent":   "MAJOR=13\nMINOR=71\nDEVNAME=input/event7\n",
+				"capabilities/ev": "3\n",
+			},
+			want: "/dev/input/event7",
+		},
+		{
+			name:  "renamed node",
+			files: map[string]string{"event9/uevent": "DEVNAME=input/by-fake/event9\n"},
+			want:  "/dev/input/by-fake/event9",
+		},
+		{
+			name:  "no uevent",
+			files: map[string]string{"event3/dev": "13:67\n"},
+			want:  "/dev/input/event3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				write(filepath.Join(dir, name), content)
+			}
+
+			got, err := eventNode(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("eventNode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := eventNode(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("eventNode() of missing directory succeeded")
+	}
+}
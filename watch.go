@@ -0,0 +1,306 @@
+package evdev
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// WatchOp describes the kind of change reported by a Watcher.
+type WatchOp int
+
+const (
+	// DeviceAdded is reported when an input device node appeared and could be opened.
+	DeviceAdded WatchOp = iota
+	// DeviceRemoved is reported when a previously added input device node disappeared.
+	DeviceRemoved
+)
+
+// WatchEvent describes an input device that was added to or removed from the system.
+// For removed devices, the information is the one gathered when the device was added.
+type WatchEvent struct {
+	Op           WatchOp
+	Path         string
+	Name         string
+	InputID      InputID
+	Capabilities map[EvType][]EvCode
+}
+
+const (
+	watchBasePath = "/dev/input"
+
+	// watchRetryTimeout is how long a node that cannot be opened yet is retried.
+	// udev usually applies permissions shortly after the node appeared.
+	watchRetryTimeout = 5 * time.Second
+	// watchRetryInterval is the interval in which such nodes are retried.
+	watchRetryInterval = 100 * time.Millisecond
+)
+
+// Watcher monitors /dev/input for input devices being added and removed.
+type Watcher struct {
+	config  watchConfig
+	fd      int
+	poller  *epoller
+	events  chan WatchEvent
+	stop    chan struct{}
+	stopped chan struct{}
+
+	known   map[string]WatchEvent
+	pending map[string]time.Time
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// watchConfig describes the directory watched by a Watcher and how the
+// device nodes in it are probed.
+type watchConfig struct {
+	dir           string
+	probe         func(path string) (*WatchEvent, bool)
+	retryInterval time.Duration
+	retryTimeout  time.Duration
+}
+
+// NewWatcher creates a new Watcher. All input devices that already exist
+// are reported as added first.
+func NewWatcher() (*Watcher, error) {
+	return newWatcher(watchConfig{
+		dir:           watchBasePath,
+		probe:         probeDevice,
+		retryInterval: watchRetryInterval,
+		retryTimeout:  watchRetryTimeout,
+	})
+}
+
+func newWatcher(config watchConfig) (*Watcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create inotify instance: %v", err)
+	}
+
+	mask := uint32(syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_ATTRIB |
+		syscall.IN_MOVED_TO | syscall.IN_MOVED_FROM)
+
+	if _, err := syscall.InotifyAddWatch(fd, config.dir, mask); err != nil {
+		_ = syscall.Close(fd)
+		return nil, fmt.Errorf("cannot watch %s: %v", config.dir, err)
+	}
+
+	p, err := newEpoller()
+	if err != nil {
+		_ = syscall.Close(fd)
+		return nil, err
+	}
+
+	if err := p.add(fd); err != nil {
+		p.close()
+		_ = syscall.Close(fd)
+		return nil, err
+	}
+
+	w := &Watcher{
+		config:  config,
+		fd:      fd,
+		poller:  p,
+		events:  make(chan WatchEvent, 16),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+		known:   make(map[string]WatchEvent),
+		pending: make(map[string]time.Time),
+	}
+
+	go w.loop()
+
+	return w, nil
+}
+
+// Events returns the channel on which device changes are reported.
+// The channel is closed when the Watcher is closed.
+func (w *Watcher) Events() <-chan WatchEvent {
+	return w.events
+}
+
+// Close stops the Watcher and closes the events channel.
+func (w *Watcher) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	close(w.stop)
+	_ = w.poller.wake()
+	<-w.stopped
+
+	w.poller.close()
+	close(w.events)
+
+	return syscall.Close(w.fd)
+}
+
+func isEventNode(name string) bool {
+	return strings.HasPrefix(name, "event")
+}
+
+func deviceCapabilities(d *InputDevice) map[EvType][]EvCode {
+	capabilities := make(map[EvType][]EvCode)
+
+	for _, t := range d.CapableTypes() {
+		capabilities[t] = d.CapableEvents(t)
+	}
+
+	return capabilities
+}
+
+// probe opens the device node and gathers its information. The returned
+// boolean is true if opening should be retried later.
+func probeDevice(path string) (*WatchEvent, bool) {
+	d, err := OpenWithFlags(path, os.O_RDONLY)
+	if err != nil {
+		retry := errors.Is(err, os.ErrPermission) || errors.Is(err, os.ErrNotExist)
+		return nil, retry
+	}
+	defer d.Close()
+
+	event := &WatchEvent{
+		Op:           DeviceAdded,
+		Path:         path,
+		Capabilities: deviceCapabilities(d),
+	}
+
+	event.Name, _ = d.Name()
+	event.InputID, _ = d.InputID()
+
+	return event, false
+}
+
+func (w *Watcher) send(event WatchEvent) bool {
+	select {
+	case w.events <- event:
+		return true
+	case <-w.stop:
+		return false
+	}
+}
+
+func (w *Watcher) added(path string) bool {
+	if _, ok := w.known[path]; ok {
+		return true
+	}
+
+	event, retry := w.config.probe(path)
+	if event == nil {
+		if retry {
+			if _, ok := w.pending[path]; !ok {
+				w.pending[path] = time.Now()
+			}
+		}
+		return true
+	}
+
+	delete(w.pending, path)
+	w.known[path] = *event
+
+	return w.send(*event)
+}
+
+func (w *Watcher) removed(path string) bool {
+	delete(w.pending, path)
+
+	event, ok := w.known[path]
+	if !ok {
+		return true
+	}
+
+	delete(w.known, path)
+	event.Op = DeviceRemoved
+
+	return w.send(event)
+}
+
+func (w *Watcher) loop() {
+	defer close(w.stopped)
+
+	entries, err := os.ReadDir(w.config.dir)
+	if err == nil {
+		for _, entry := range entries {
+			if isEventNode(entry.Name()) && !w.added(filepath.Join(w.config.dir, entry.Name())) {
+				return
+			}
+		}
+	}
+
+	buffer := make([]byte, 4096)
+
+	for {
+		timeout := -1
+		if len(w.pending) > 0 {
+			timeout = int(w.config.retryInterval / time.Millisecond)
+		}
+
+		fds, err := w.poller.wait(timeout)
+
+		select {
+		case <-w.stop:
+			return
+		default:
+		}
+
+		if err != nil {
+			return
+		}
+
+		for path, since := range w.pending {
+			if time.Since(since) > w.config.retryTimeout {
+				delete(w.pending, path)
+				continue
+			}
+
+			if !w.added(path) {
+				return
+			}
+		}
+
+		if len(fds) == 0 {
+			continue
+		}
+
+		n, err := syscall.Read(w.fd, buffer)
+		if err != nil {
+			continue
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buffer[offset]))
+			nameBytes := buffer[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+			offset += syscall.SizeofInotifyEvent + int(event.Len)
+
+			name := trimNull(string(nameBytes))
+			if !isEventNode(name) {
+				continue
+			}
+
+			path := filepath.Join(w.config.dir, name)
+
+			ok := true
+			switch {
+			case event.Mask&(syscall.IN_DELETE|syscall.IN_MOVED_FROM) != 0:
+				ok = w.removed(path)
+			case event.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO|syscall.IN_ATTRIB) != 0:
+				ok = w.added(path)
+			}
+
+			if !ok {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package evdev
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeProbe probes files instead of device nodes. A file without read
+// permission is retried, like a node udev has not set the permissions of yet.
+type fakeProbe struct {
+	calls int32
+}
+
+func (f *fakeProbe) probe(path string) (*WatchEvent, bool) {
+	atomic.AddInt32(&f.calls, 1)
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, os.IsNotExist(err)
+	}
+
+	if fi.Mode().Perm()&0400 == 0 {
+		return nil, true
+	}
+
+	return &WatchEvent{Op: DeviceAdded, Path: path, Name: filepath.Base(path)}, false
+}
+
+func newTestWatcher(t *testing.T, dir string, retryInterval, retryTimeout time.Duration) (*Watcher, *fakeProbe) {
+	probe := &fakeProbe{}
+
+	w, err := newWatcher(watchConfig{
+		dir:           dir,
+		probe:         probe.probe,
+		retryInterval: retryInterval,
+		retryTimeout:  retryTimeout,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { w.Close() })
+
+	return w, probe
+}
+
+func createNode(t *testing.T, path string, perm os.FileMode) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, perm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+}
+
+func nextWatchEvent(t *testing.T, w *Watcher) WatchEvent {
+	select {
+	case event := <-w.Events():
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no watch event")
+		return WatchEvent{}
+	}
+}
+
+func expectNoWatchEvent(t *testing.T, w *Watcher, d time.Duration) {
+	select {
+	case event := <-w.Events():
+		t.Errorf("unexpected watch event %+v", event)
+	case <-time.After(d):
+	}
+}
+
+func TestWatcherScanCreateDelete(t *testing.T) {
+	dir := t.TempDir()
+	createNode(t, filepath.Join(dir, "event0"), 0644)
+	createNode(t, filepath.Join(dir, "mouse0"), 0644)
+
+	w, _ := newTestWatcher(t, dir, 10*time.Millisecond, time.Second)
+
+	if event := nextWatchEvent(t, w); event.Op != DeviceAdded || event.Name != "event0" {
+		t.Errorf("initial scan reported %+v, want event0 added", event)
+	}
+
+	path := filepath.Join(dir, "event1")
+	createNode(t, path, 0644)
+
+	if event := nextWatchEvent(t, w); event.Op != DeviceAdded || event.Path != path {
+		t.Errorf("got %+v, want %s added", event, path)
+	}
+
+	// not an event node
+	createNode(t, filepath.Join(dir, "js0"), 0644)
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if event := nextWatchEvent(t, w); event.Op != DeviceRemoved || event.Path != path || event.Name != "event1" {
+		t.Errorf("got %+v, want %s removed", event, path)
+	}
+
+	expectNoWatchEvent(t, w, 20*time.Millisecond)
+}
+
+func TestWatcherRetryOnAttrib(t *testing.T) {
+	dir := t.TempDir()
+	// only the inotify event can make the watcher retry in time
+	w, _ := newTestWatcher(t, dir, time.Minute, time.Minute)
+
+	path := filepath.Join(dir, "event3")
+	createNode(t, path, 0)
+
+	expectNoWatchEvent(t, w, 30*time.Millisecond)
+
+	// udev applying the permissions
+	if err := os.Chmod(path, 0660); err != nil {
+		t.Fatal(err)
+	}
+
+	if event := nextWatchEvent(t, w); event.Op != DeviceAdded || event.Path != path {
+		t.Errorf("got %+v, want %s added", event, path)
+	}
+}
+
+func TestWatcherRetryTimeout(t *testing.T) {
+	dir := t.TempDir()
+	w, probe := newTestWatcher(t, dir, 10*time.Millisecond, 30*time.Millisecond)
+
+	createNode(t, filepath.Join(dir, "event4"), 0)
+
+	expectNoWatchEvent(t, w, 100*time.Millisecond)
+
+	calls := atomic.LoadInt32(&probe.calls)
+	if calls < 2 {
+		t.Errorf("node probed %d times, want retries", calls)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	if got := atomic.LoadInt32(&probe.calls); got != calls {
+		t.Errorf("node probed %d more times after the retry timeout", got-calls)
+	}
+}
+
+func TestWatcherCloseConcurrent(t *testing.T) {
+	w, _ := newTestWatcher(t, t.TempDir(), 10*time.Millisecond, time.Second)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = w.Close()
+		}()
+	}
+	wg.Wait()
+
+	if _, ok := <-w.Events(); ok {
+		t.Error("events channel not closed")
+	}
+}